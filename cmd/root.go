@@ -2,39 +2,249 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"go.dalton.dog/campfire/internal/models"
 
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/fang"
 	"github.com/charmbracelet/log"
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 )
 
 const Version = "0.9.2"
 
+var (
+	keepRotated  bool
+	withRotated  bool
+	pollInterval time.Duration
+	recordStart  string
+	format       string
+	formats      []string
+	customLevels []string
+	minLevel     string
+	contextLines int
+	maxLines     int
+	lazyOver     string
+	since        string
+	until        string
+	layoutName   string
+)
+
 var rootCmd = &cobra.Command{
-	Use:   "campfire <./path/to/file>",
+	Use:   "campfire [<./path/to/file>... | -] [-- command args...]",
 	Short: "A quick and stylish log viewer",
 	Long:  "Get cozy with your logs with campfire, a fast and beautiful log viewer!",
-	Args:  cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			if dash == len(args) {
+				return errors.New("missing the command to run after --")
+			}
+			return nil
+		}
+
+		// Logs can be piped in instead of naming a file
+		if stdinIsTerminal() {
+			return cobra.MinimumNArgs(1)(cmd, args)
+		}
+
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		model := models.NewModel(args[0])
+		// Everything after -- is a command to run
+		var command []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, command = args[:dash], args[dash:]
+		}
+
+		if len(args) == 0 && len(command) == 0 {
+			args = []string{models.StdinName}
+		}
+
+		opts := models.Options{
+			KeepRotated:  keepRotated,
+			WithRotated:  withRotated,
+			Command:      command,
+			PollInterval: pollInterval,
+			Context:      contextLines,
+			MaxLines:     maxLines,
+		}
+
+		if contextLines < 0 {
+			log.Fatalf("Invalid --context %d, it can't be negative", contextLines)
+		}
+
+		if maxLines < 0 {
+			log.Fatalf("Invalid --max-lines %d, it can't be negative", maxLines)
+		}
+
+		threshold, err := humanize.ParseBytes(lazyOver)
+		if err != nil {
+			log.Fatalf("Invalid --lazy-over:\n%v", err)
+		}
+		opts.LazyThreshold = int64(threshold)
+
+		if recordStart != "" {
+			pattern, err := regexp.Compile(recordStart)
+			if err != nil {
+				log.Fatalf("Invalid --record-start pattern:\n%v", err)
+			}
+			opts.RecordStart = pattern
+		}
+
+		for _, spec := range customLevels {
+			level, err := models.ParseLevelSpec(spec)
+			if err != nil {
+				log.Fatalf("Invalid --custom-level:\n%v", err)
+			}
+			if _, err := models.RegisterLevel(level); err != nil {
+				log.Fatalf("Couldn't add level %s:\n%v", level.Name, err)
+			}
+		}
+
+		if minLevel != "" {
+			level, ok := models.LookupLevel(minLevel)
+			if !ok {
+				log.Fatalf("Unknown --min-level %q", minLevel)
+			}
+			opts.MinSeverity = level.Severity()
+			opts.Threshold = true
+		}
+
+		now := time.Now()
+		if since != "" {
+			bound, err := models.ParseTimeBound(since, now)
+			if err != nil {
+				log.Fatalf("Invalid --since:\n%v", err)
+			}
+			opts.Since = bound
+		}
+		if until != "" {
+			bound, err := models.ParseTimeBound(until, now)
+			if err != nil {
+				log.Fatalf("Invalid --until:\n%v", err)
+			}
+			opts.Until = bound
+		}
 
-		p := tea.NewProgram(
-			model,
+		if layoutName != "" {
+			layout, err := models.ParseLayout(layoutName)
+			if err != nil {
+				log.Fatalf("Invalid --layout:\n%v", err)
+			}
+			opts.Layout = layout
+		}
+
+		for _, spec := range formats {
+			parser, err := models.ParseFormatSpec(spec)
+			if err != nil {
+				log.Fatalf("Invalid --custom-format:\n%v", err)
+			}
+			if err := models.RegisterParser(parser); err != nil {
+				log.Fatalf("Couldn't add format %s:\n%v", parser.Name(), err)
+			}
+		}
+
+		if format != "" {
+			parser, ok := models.LookupParser(format)
+			if !ok {
+				log.Fatalf("Unknown --format %q, expected one of: %v", format, strings.Join(models.ParserNames(), ", "))
+			}
+			opts.Parser = parser
+		}
+
+		filenames, err := expandGlobs(args)
+		if err != nil {
+			log.Fatalf("Couldn't find the files to watch:\n%v", err)
+		}
+		if len(command) > 0 && len(filenames) > models.MaxSources-2 {
+			log.Fatalf("Too many files, campfire can watch up to %d at once alongside a command", models.MaxSources-2)
+		}
+		if len(filenames) > models.MaxSources {
+			log.Fatalf("Too many files, campfire can watch up to %d at once", models.MaxSources)
+		}
+
+		model := models.NewModel(filenames, opts)
+
+		programOpts := []tea.ProgramOption{
 			tea.WithAltScreen(),       // Use the full size of the terminal
 			tea.WithMouseCellMotion(), // Enable tracking the mouse wheel
-		)
+		}
+
+		// Stdin is busy with the logs, so keys have to come straight from the terminal
+		if slices.Contains(filenames, models.StdinName) {
+			programOpts = append(programOpts, tea.WithInputTTY())
+		}
+
+		p := tea.NewProgram(model, programOpts...)
 
 		if _, err := p.Run(); err != nil {
+			model.Stop()
 			log.Fatalf("Error running program:\n%v", err)
 		}
 	},
 }
 
+// expandGlobs expands any glob patterns among the arguments, for when the shell didn't (like when they're quoted).
+// Plain paths are kept even if they don't exist yet, so campfire can wait for them to show up
+func expandGlobs(args []string) ([]string, error) {
+	var filenames []string
+	seen := make(map[string]bool)
+
+	for _, arg := range args {
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("bad pattern %q: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", arg)
+			}
+		}
+
+		for _, name := range matches {
+			if !seen[name] {
+				seen[name] = true
+				filenames = append(filenames, name)
+			}
+		}
+	}
+
+	return filenames, nil
+}
+
+// stdinIsTerminal reports whether stdin is a terminal, rather than something being piped in
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	rootCmd.Flags().DurationVar(&pollInterval, "poll-interval", models.DefaultPollInterval, "how often to check the file when change events aren't available (e.g. on NFS)")
+	rootCmd.Flags().StringVar(&format, "format", "", "log format to use instead of detecting it ("+strings.Join(models.ParserNames(), ", ")+")")
+	rootCmd.Flags().StringArrayVar(&formats, "custom-format", nil, "add a log format, as NAME=REGEX with named groups like level, time and msg (repeatable)")
+	rootCmd.Flags().StringVar(&minLevel, "min-level", "", "hide levels less severe than this one, and lines without a level, e.g. warn")
+	rootCmd.Flags().StringVar(&since, "since", "", "hide records logged before this, as a duration ago (10m) or a timestamp")
+	rootCmd.Flags().StringVar(&until, "until", "", "hide records logged after this, as a duration ago (10m) or a timestamp")
+	rootCmd.Flags().StringVar(&layoutName, "layout", "", "how to arrange several files: merged, tabs, columns (side by side) or rows (stacked)")
+	rootCmd.Flags().IntVarP(&contextLines, "context", "C", 0, "records to show before and after each one that matches the filters, like grep -C")
+	rootCmd.Flags().StringArrayVar(&customLevels, "custom-level", nil, "add a log level, as NAME[:COLOR[:SEVERITY[:KEY]]] (repeatable)")
+	rootCmd.Flags().StringVar(&recordStart, "record-start", "", "regex matching the first line of each log record; other lines attach to the record before them")
+	rootCmd.Flags().IntVar(&maxLines, "max-lines", 0, "keep at most this many lines from each file, dropping the oldest records whole (0 for no limit)")
+	rootCmd.Flags().StringVar(&lazyOver, "lazy-over", humanize.IBytes(models.DefaultLazyThreshold), "index files this big or bigger and only read what's in view, instead of reading them whole (0 to never)")
+	rootCmd.Flags().BoolVar(&withRotated, "with-rotated", false, "also show the files each log was rotated into (app.log.1, app.log.2.gz, ...), oldest first")
+	rootCmd.Flags().BoolVar(&keepRotated, "keep-rotated", false, "keep lines from before a log rotation so you can scroll back across it")
+}
+
 func Execute() {
 	if err := fang.Execute(context.Background(), rootCmd, fang.WithoutManpage(), fang.WithoutCompletions(), fang.WithVersion(Version)); err != nil {
 		fmt.Fprintln(os.Stderr, err)
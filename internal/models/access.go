@@ -0,0 +1,62 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// accessPattern matches the common and combined log formats used by Apache and nginx, i.e.
+// `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /index.html HTTP/1.0" 200 2326 "referer" "agent"`
+var accessPattern = regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] "([^"]*)" (\d{3}) (\S+)(?: "([^"]*)" "([^"]*)")?`)
+
+// accessParser reads web server access logs
+type accessParser struct{}
+
+func (accessParser) Name() string { return "access" }
+
+func (accessParser) Detect(line string) bool {
+	return accessPattern.MatchString(line)
+}
+
+func (accessParser) Parse(text string) (Record, bool) {
+	match := accessPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Record{}, false
+	}
+
+	r := Record{
+		Level:   accessLevel(match[6]),
+		Time:    match[4],
+		Message: match[5],
+	}
+
+	fields := []Field{
+		{"status", match[6]},
+		{"bytes", match[7]},
+		{"remote", match[1]},
+		{"user", match[3]},
+		{"referer", match[8]},
+		{"agent", match[9]},
+	}
+	for _, field := range fields {
+		if field.Value != "" && field.Value != "-" {
+			r.Fields = append(r.Fields, field)
+		}
+	}
+
+	return r, true
+}
+
+// accessLevel treats server errors as errors, and client errors as warnings
+func accessLevel(status string) LogLevel {
+	code, _ := strconv.Atoi(status)
+
+	switch {
+	case code >= 500:
+		return ErrorLevel
+	case code >= 400:
+		return WarnLevel
+	}
+
+	return InfoLevel
+}
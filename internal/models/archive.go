@@ -0,0 +1,98 @@
+package models
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// rotatedSuffix matches what log rotation adds to the end of a file's name: a number like .1 (logrotate's default),
+// or a date like -20240115 (its dateext option), and maybe a compression extension after that
+var rotatedSuffix = regexp.MustCompile(`^(?:\.(\d+)|-(\d{8,10}))(?:\.(?:gz|zst|bz2))?$`)
+
+// findRotated finds the files a log has been rotated into, oldest first.
+// Dated ones come before numbered ones, which count up from the newest
+func findRotated(name string) []string {
+	type rotated struct {
+		name   string
+		number int    // For numbered files, where bigger is older
+		date   string // For dated files, which sort in order
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(name))
+	if err != nil {
+		return nil
+	}
+
+	base := filepath.Base(name)
+	var found []rotated
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), base)
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		match := rotatedSuffix.FindStringSubmatch(suffix)
+		if match == nil {
+			continue
+		}
+
+		number, _ := strconv.Atoi(match[1])
+		found = append(found, rotated{filepath.Join(filepath.Dir(name), entry.Name()), number, match[2]})
+	}
+
+	slices.SortFunc(found, func(a, b rotated) int {
+		switch {
+		case a.date != "" && b.date != "":
+			return cmp.Compare(a.date, b.date)
+		case a.date != "":
+			return -1
+		case b.date != "":
+			return 1
+		}
+
+		return cmp.Compare(b.number, a.number)
+	})
+
+	names := make([]string, len(found))
+	for i, r := range found {
+		names[i] = r.name
+	}
+
+	return names
+}
+
+// readArchives reads the files a source's log was rotated into, and parses them into records to go before
+// its own. Each file's records are preceded by a marker naming it, and a last marker names the live file
+func readArchives(src int, name string, opts Options) tea.Cmd {
+	return func() tea.Msg {
+		names := findRotated(name)
+		if len(names) == 0 {
+			return nil
+		}
+
+		// A scratch source does the parsing, so the records come out the same as the live file's
+		scratch := &source{id: src, options: opts, parser: opts.Parser}
+		for _, archive := range names {
+			content, err := readLogFile(archive)
+
+			scratch.tail = tailer{}
+			scratch.content = append(scratch.content, scratch.marker(filepath.Base(archive)))
+			if err != nil {
+				scratch.content = append(scratch.content, scratch.marker("couldn't read "+filepath.Base(archive)+": "+err.Error()))
+				continue
+			}
+
+			scratch.appendContent(content)
+		}
+		scratch.content = append(scratch.content, scratch.marker(filepath.Base(name)))
+
+		return archivesReadMsg{src, scratch.content}
+	}
+}
@@ -4,53 +4,199 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
-	"strings"
 
 	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/v2/help"
 	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/progress"
 	"github.com/charmbracelet/bubbles/v2/textinput"
-	"github.com/charmbracelet/bubbles/v2/viewport"
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 )
 
-const tickRate = time.Millisecond * 750
+// filterPlaceholder is shown in the text input when there's no filter
+const filterPlaceholder = "<query, or /regex>"
+
+// DefaultPollInterval is how often the file gets checked when no change events come in
+const DefaultPollInterval = time.Millisecond * 750
+
+// safetyNetInterval is how often files get checked anyway while their change events are coming in, in case one's missed
+const safetyNetInterval = time.Second * 30
 
 // Messages
 
+// Messages about a file say which of the sources it is
+
 type tickMsg time.Time
-type fileGoneMsg struct{}
+type safetyNetMsg time.Time
+type fileChangedMsg struct{ source int }
+type watcherStartedMsg struct {
+	source  int
+	watcher *watcher
+}
+type watcherFailedMsg struct{ source int }
+type fileGoneMsg struct{ source int }
 type fileExistsMsg struct {
-	info    fs.FileInfo
+	source   int
+	info     fs.FileInfo
+	content  []byte   // Bytes read starting at the source's current offset
+	offset   int64    // Where the next read should start
+	reload   bool     // Whether content is a fresh read from the start of the file
+	rotation rotation // How the file changed since the last check, if it did
+}
+type fileErrorMsg struct {
+	source int
+	err    error
+}
+type streamReadMsg struct {
+	source  int
+	stream  *stream // The stream that was read, which is stale if the source has been restarted since
 	content []byte
+	err     error // Why the stream ended, if it did
+}
+type fileHugeMsg struct {
+	source   int
+	info     fs.FileInfo
+	rotation rotation
+	index    *os.File // Where to keep the file's index
+}
+type indexedMsg struct {
+	source  int
+	index   *os.File // The index that was added to, which is stale if the file has started over since
+	info    fs.FileInfo
+	entries int64
+	lines   int64
+	indexed int64
+	scanned int64
+	open    *record
+	midLine bool
+	pending bool
+	parser  Parser
+}
+type windowLoadedMsg struct {
+	source  int
+	index   *os.File // The index the window was read with, which is stale if the file has been indexed again since
+	first   int64
+	records []LogMessage
+	end     int64 // Where in the file the last record ended
+	anchor  windowAnchor
+	err     error
+}
+type archivesReadMsg struct {
+	source  int
+	content []LogMessage
 }
-type fileErrorMsg error
-type viewportUpdateMsg []string
+type commandStartMsg struct{}
+type commandExitedMsg struct {
+	cmd  *exec.Cmd // The run of the program that exited
+	code int
+	err  error // Why waiting on it failed, if it wasn't just a non-zero exit
+}
+type viewportUpdateMsg struct {
+	pane    *pane // The pane the lines are for
+	render  int   // Which of the pane's renders this is, so an older one finishing late can be dropped
+	lines   []string
+	records []viewRecord  // Where each shown record starts in lines
+	matches []searchMatch // Where the search matches are in lines, if searching
+}
+
+// inputMode is what the footer's text input is being used for
+type inputMode int
+
+const (
+	filterInput    inputMode = iota // Editing the filter text
+	excludeInput                    // Typing a new exclusion
+	unexcludeInput                  // Typing the number of an exclusion to take off
+	searchInput                     // Typing something to search for
+	gotoInput                       // Typing a line number or time to jump to
+)
+
+// Options holds the command line settings that change how campfire behaves
+type Options struct {
+	KeepRotated  bool          // Keep the lines from before a log rotation instead of clearing them
+	PollInterval time.Duration // How often to check the file, in case change events are missed
+
+	// RecordStart matches the first line of each log record. Lines that don't match get
+	// attached to the record before them. If nil, records are detected heuristically
+	RecordStart *regexp.Regexp
+
+	// Parser forces the log format to use. If nil, it's detected from the top of the file
+	Parser Parser
+
+	// MinSeverity hides levels less severe than it to begin with, along with OTHER, if Threshold is set
+	MinSeverity int
+	Threshold   bool
+
+	Context int // Records of context to show around each one the filters let through, to begin with
+
+	Since, Until time.Time // Hide records logged outside of these, if they're set
+
+	Layout layout // How to arrange the files when there's more than one, to begin with
+
+	Command []string // A program to run, showing its stdout and stderr alongside any files
+
+	WithRotated bool // Read the files each log was rotated into, like app.log.1 and app.log.2.gz, and show them first
 
-// NewModel actually creates the main campfire model
-func NewModel(filename string) *model {
+	MaxLines int // Keep at most this many lines from each source, dropping the oldest records whole. Unlimited if 0
+
+	// LazyThreshold is how big a file has to be before it's indexed and read a window at a time. Never if 0
+	LazyThreshold int64
+}
+
+// NewModel actually creates the main campfire model, watching each of the files and
+// starting the command, if there is one. There can be at most MaxSources sources between them
+func NewModel(filenames []string, opts Options) *model {
 	// Viewport is initialized in after window size message
 
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+
 	text := textinput.New()
-	text.Placeholder = "<text filter>"
-	text.Prompt = "Substring: "
+	text.Placeholder = filterPlaceholder
+	text.Prompt = "Query: "
 
 	m := model{
-		filename:  filename,
+		sources:   newSources(filenames, opts),
+		options:   opts,
 		keys:      GetKeymap(),
 		textInput: text,
+		polling:   true, // Init starts it
 		help:      help.New(),
-		filters: Filters{
-			ShowInfo:  true,
-			ShowWarn:  true,
-			ShowError: true,
-			ShowDebug: true,
-			ShowFatal: true,
-			ShowOther: true,
-		},
+		progress:  progress.New(progress.WithDefaultGradient(), progress.WithWidth(20)),
+	}
+
+	if len(opts.Command) > 0 {
+		m.command = newCommand(opts.Command, len(m.sources), opts) // Init starts it
+		m.sources = append(m.sources, m.command.sources()...)
+	} else {
+		m.keys.Restart.SetEnabled(false)
+	}
+
+	filters := Filters{
+		MinSeverity: opts.MinSeverity,
+		Threshold:   opts.Threshold,
+		Context:     opts.Context,
+		Since:       opts.Since,
+		Until:       opts.Until,
+	}
+	m.merged = newPane(m.sources, filters, renderOptions{tags: m.sourceTags()})
+	m.pane = m.merged
+	m.setLayout(opts.Layout)
+
+	// There's nothing to switch between with only one file
+	if len(m.sources) < 2 {
+		m.keys.CycleLayout.SetEnabled(false)
+		m.keys.NextPane.SetEnabled(false)
+		m.keys.PrevPane.SetEnabled(false)
 	}
 
 	return &m
@@ -58,27 +204,55 @@ func NewModel(filename string) *model {
 
 // model is the BubbleTea model for campfire
 type model struct {
-	filename      string
-	content       []LogMessage
-	viewport      viewport.Model
+	sources       []*source
+	options       Options
 	width, height int
 	ready         bool
+	polling       bool // Whether ticks are being sent to poll the files without working watchers
 
-	keys Keymap
-	help help.Model
+	keys     Keymap
+	help     help.Model
+	progress progress.Model // Shows how far along indexing huge files is
 
 	textInput  textinput.Model
 	textActive bool
+	inputMode  inputMode // What the text input is being used for while it's focused
+	inputErr   error     // Why what's being typed can't be used, if it can't, for inputs that aren't applied as they're typed
 
-	filters Filters
+	// The focused pane, which keys act on. Its viewport, filters and so on are the model's own
+	*pane
 
-	fileExists   bool
-	prevFileInfo fs.FileInfo
+	command *command // The program being run, if campfire was asked to run one
+
+	merged    *pane   // Every file in one pane
+	filePanes []*pane // A pane for each file, made the first time the layout splits them up
+	layout    layout
 }
 
-// Init kicks off the ticking
+// Init kicks off the ticking, and the watcher and first check for each file
 func (m model) Init() tea.Cmd {
-	return tea.Batch(tickCmd(), checkFile(m.filename))
+	// Polling starts out on, since the watchers take a moment to start
+	cmds := []tea.Cmd{tickCmd(m.options.PollInterval), safetyNetCmd()}
+	for _, src := range m.sources {
+		switch {
+		case src.stream != nil:
+			cmds = append(cmds, readStream(src.id, src.stream))
+		case src.command != nil:
+			// The program's output is read once it's started, which waits until the program is running
+			// so nothing is left behind if campfire can't get going
+		default:
+			cmds = append(cmds, startWatcher(src.id, src.filename), checkFile(src.id, src.filename, nil, 0, m.options.LazyThreshold))
+			if m.options.WithRotated {
+				cmds = append(cmds, readArchives(src.id, src.filename, m.options))
+			}
+		}
+	}
+
+	if m.command != nil {
+		cmds = append(cmds, func() tea.Msg { return commandStartMsg{} })
+	}
+
+	return tea.Batch(cmds...)
 }
 
 // Update processes new messages for the model
@@ -92,151 +266,402 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
-		m.help.Width = m.width
-		textWidth := m.width - lipgloss.Width(m.textInput.Prompt) - lipgloss.Width(LevelFilterString) - borderStyle.GetHorizontalBorderSize()
-		m.textInput.SetWidth(max(textWidth, 0))
-
-		headerHeight := lipgloss.Height(m.Header())
-		footerHeight := lipgloss.Height(m.Footer())
-		verticalMarginHeight := headerHeight + footerHeight
-
-		viewportStyle = viewportStyle.Width(m.width).Height(m.height - verticalMarginHeight)
-
-		if !m.ready {
-			m.viewport = viewport.New()
-
-			m.viewport.SoftWrap = true
-			m.fileExists = false
-			m.ready = true
-		}
-
-		m.viewport.SetWidth(m.width)
-		m.viewport.SetHeight(m.height - viewportStyle.GetVerticalBorderSize())
-		m.viewport.Style = viewportStyle
+		m.ready = true
+		m.resize()
 
 	case tea.KeyPressMsg:
+		scrolled := scrollNone
 
 		switch m.textActive {
 		case true:
 			switch {
 			case key.Matches(msg, m.keys.Quit):
-				return m, tea.Quit
+				return m.quit()
 			case key.Matches(msg, m.keys.FocusedClearFilter):
-				m.textActive = false
-				m.filters.FilterText = ""
-				m.textInput.SetValue("")
-				m.textInput.Blur()
-
-				m.keys.FocusFilter.SetEnabled(true)
-				m.keys.NoFocusClearFilter.SetEnabled(true)
-				m.keys.FocusedClearFilter.SetEnabled(false)
-				m.keys.SaveFilter.SetEnabled(false)
+				switch m.inputMode {
+				case filterInput:
+					m.filters.SetFilterText("")
+					m.textInput.SetValue("")
+				case searchInput:
+					m.search.set("", false)
+					m.render.search = nil
+				}
+				m.blurInput()
 			case key.Matches(msg, m.keys.SaveFilter):
-				m.textActive = false
-				m.filters.FilterText = m.textInput.Value()
-				m.textInput.Blur()
-
-				m.keys.FocusFilter.SetEnabled(true)
-				m.keys.NoFocusClearFilter.SetEnabled(true)
-				m.keys.FocusedClearFilter.SetEnabled(false)
-				m.keys.SaveFilter.SetEnabled(false)
+				switch m.inputMode {
+				case filterInput:
+					m.filters.SetFilterText(m.textInput.Value())
+				case excludeInput:
+					m.inputErr = m.filters.AddExclusion(m.textInput.Value())
+					m.shareExclusions()
+				case unexcludeInput:
+					m.inputErr = m.removeExclusion(m.textInput.Value())
+				case gotoInput:
+					if cmd, ok := m.jumpToLine(m.textInput.Value()); ok {
+						cmds = append(cmds, cmd)
+					} else {
+						m.inputErr = m.jumpTo(m.textInput.Value())
+					}
+				}
+
+				// Leave the input up if what's in it can't be used, so it can be fixed
+				if m.inputErr == nil {
+					m.blurInput()
+				}
+			case key.Matches(msg, m.keys.ToggleCase):
+				m.toggleIgnoreCase()
+
+			// Backspacing past the start of a new exclusion takes off the last one, like a list of tags
+			case m.inputMode == excludeInput && msg.String() == "backspace" && m.textInput.Value() == "":
+				m.removeExclusion("")
+
 			default:
 				m.textInput, cmd = m.textInput.Update(msg)
 				cmds = append(cmds, cmd)
-				m.filters.FilterText = m.textInput.Value()
+
+				switch m.inputMode {
+				case filterInput:
+					m.filters.SetFilterText(m.textInput.Value())
+				case excludeInput:
+					_, m.inputErr = compileMatcher(m.textInput.Value(), m.filters.IgnoreCase)
+				case searchInput:
+					m.search.set(m.textInput.Value(), m.filters.IgnoreCase)
+					m.render.search = m.search.pattern
+				case gotoInput, unexcludeInput:
+					m.inputErr = nil
+				}
 			}
 
 		case false:
+			level, isToggle := m.keys.LevelToggle(msg)
+			src, isSourceToggle := m.keys.SourceToggle(msg, m.sourceToggleCount())
+
 			switch {
 			case key.Matches(msg, m.keys.Quit):
-				return m, tea.Quit
+				return m.quit()
 
 			case msg.String() == "q": // Let q be a sneaky quit key if text field inactive
-				return m, tea.Quit
-
-			// Level filter toggles
-			case key.Matches(msg, m.keys.ToggleInfo):
-				m.filters.ShowInfo = !m.filters.ShowInfo
-			case key.Matches(msg, m.keys.ToggleWarn):
-				m.filters.ShowWarn = !m.filters.ShowWarn
-			case key.Matches(msg, m.keys.ToggleError):
-				m.filters.ShowError = !m.filters.ShowError
-			case key.Matches(msg, m.keys.ToggleDebug):
-				m.filters.ShowDebug = !m.filters.ShowDebug
-			case key.Matches(msg, m.keys.ToggleFatal):
-				m.filters.ShowFatal = !m.filters.ShowFatal
-			// case key.Matches(msg, m.keys.ToggleOther):
-			// 	m.filters.ShowOther = !m.filters.ShowOther
+				return m.quit()
+
+			// Level and source filter toggles
+			case isToggle:
+				m.filters.ToggleLevel(level)
+			case isSourceToggle:
+				m.filters.ToggleSource(src)
+			case key.Matches(msg, m.keys.RaiseMinLevel):
+				m.filters.RaiseThreshold()
+			case key.Matches(msg, m.keys.LowerMinLevel):
+				m.filters.LowerThreshold()
+
+			case key.Matches(msg, m.keys.CycleTimeWindow):
+				m.filters.CycleWindow()
+
+			case key.Matches(msg, m.keys.MoreContext):
+				m.filters.Context++
+			case key.Matches(msg, m.keys.LessContext):
+				m.filters.Context = max(m.filters.Context-1, 0)
+
+			case key.Matches(msg, m.keys.ToggleFields):
+				m.render.hideFields = !m.render.hideFields
 
 			// Keyword filtering
 			case key.Matches(msg, m.keys.FocusFilter):
-				m.textActive = true
-				m.textInput.Focus()
+				m.focusInput(filterInput)
 
-				m.keys.FocusFilter.SetEnabled(false)
-				m.keys.NoFocusClearFilter.SetEnabled(false)
-				m.keys.FocusedClearFilter.SetEnabled(true)
-				m.keys.SaveFilter.SetEnabled(true)
+			// Exclusions
+			case key.Matches(msg, m.keys.AddExclusion):
+				m.focusInput(excludeInput)
+			case key.Matches(msg, m.keys.RemoveExclusion):
+				if len(m.filters.Exclusions()) > 0 {
+					m.focusInput(unexcludeInput)
+				}
 
 			case key.Matches(msg, m.keys.NoFocusClearFilter):
 				m.textInput.SetValue("")
-				m.filters.FilterText = ""
+				m.filters.SetFilterText("")
+
+			case key.Matches(msg, m.keys.ToggleCase):
+				m.toggleIgnoreCase()
+
+			// Searching
+			case key.Matches(msg, m.keys.Search):
+				m.focusInput(searchInput)
+			case key.Matches(msg, m.keys.NextMatch):
+				m.search.step(1)
+				m.showLines()
+			case key.Matches(msg, m.keys.PrevMatch):
+				m.search.step(-1)
+				m.showLines()
+
+			case key.Matches(msg, m.keys.GoTo):
+				m.focusInput(gotoInput)
 
-			// Viewport things
+			case key.Matches(msg, m.keys.Restart):
+				m.command.restart()
+				for _, p := range m.allPanes() {
+					p.content = mergeSources(p.files)
+				}
+				cmds = append(cmds, m.command.reads())
+
+			// Panes
+			case key.Matches(msg, m.keys.CycleLayout):
+				m.setLayout((m.layout + 1) % layout(len(layoutNames)))
+			case key.Matches(msg, m.keys.NextPane):
+				m.switchPane(1)
+			case key.Matches(msg, m.keys.PrevPane):
+				m.switchPane(-1)
+
+			// Viewport things. Scrolling up stops following new lines, so they don't pull the view away
 			case key.Matches(msg, m.keys.LineUp):
 				m.viewport.LineUp(1)
+				m.pauseFollowing()
+				scrolled = scrollUp
 			case key.Matches(msg, m.keys.LineDn):
 				m.viewport.LineDown(1)
+				scrolled = scrollDown
 
 			case key.Matches(msg, m.keys.PageUp):
 				m.viewport.ViewUp()
+				m.pauseFollowing()
+				scrolled = scrollUp
 			case key.Matches(msg, m.keys.PageDn):
 				m.viewport.ViewDown()
+				scrolled = scrollDown
 
 			case key.Matches(msg, m.keys.HalfPgUp):
 				m.viewport.HalfViewUp()
+				m.pauseFollowing()
+				scrolled = scrollUp
 			case key.Matches(msg, m.keys.HalfPgDn):
 				m.viewport.HalfViewDown()
+				scrolled = scrollDown
 
 			case key.Matches(msg, m.keys.GoToTop):
 				m.viewport.GotoTop()
-			case key.Matches(msg, m.keys.GoToEnd):
-				m.viewport.GotoBottom()
+				m.pauseFollowing()
+				scrolled = scrollTop
+			case key.Matches(msg, m.keys.GoToEnd), key.Matches(msg, m.keys.Follow):
+				m.resumeFollowing()
 			}
 
 		}
-		cmds = append(cmds, updateViewport(m.content, m.filters))
+		m.textInput.Prompt = m.filterPrompt()
+		m.resize()
+		cmds = append(cmds, m.moveWindows(scrolled)...)
+		for _, p := range m.visiblePanes() {
+			cmds = append(cmds, updateViewport(p))
+		}
 
 	case tea.MouseWheelMsg:
 		m.viewport, cmd = m.viewport.Update(msg)
 		cmds = append(cmds, cmd)
 
+		scrolled := scrollNone
+		switch msg.Button {
+		case tea.MouseWheelUp:
+			m.pauseFollowing()
+			scrolled = scrollUp
+		case tea.MouseWheelDown:
+			scrolled = scrollDown
+		}
+		cmds = append(cmds, m.moveWindows(scrolled)...)
+
 	case fileExistsMsg:
-		m.prevFileInfo = msg.info
-		m.fileExists = true
-		m.content = make([]LogMessage, 0)
-		// TODO: Make this handle multiple messages that span multiple lines
-		for i, message := range strings.Split(string(msg.content), "\n") {
-			logMsg := NewLogMessage(i, message)
-			m.content = append(m.content, logMsg)
+		src := m.sources[msg.source]
+		src.prevFileInfo = msg.info
+		src.fileExists = true
+
+		if msg.reload {
+			src.startOver(msg.rotation)
+
+			// What was just rotated away is one of the archives now, unless it's being kept anyway
+			if msg.rotation != notRotated && m.options.WithRotated && !m.options.KeepRotated {
+				cmds = append(cmds, readArchives(src.id, src.filename, m.options))
+			}
+		}
+
+		// Only redraw if something new was actually written
+		if len(msg.content) > 0 || msg.reload {
+			read := src.tail.lines
+			src.appendContent(msg.content)
+			src.tail.offset = msg.offset
+
+			cmds = append(cmds, m.refreshPanes(src, src.tail.lines-read)...)
+		}
+
+		cmds = append(cmds, src.finishCheck())
+
+	case streamReadMsg:
+		src := m.sources[msg.source]
+		if msg.stream != src.stream {
+			break
 		}
+		src.fileExists = true
 
-		cmds = append(cmds, updateViewport(m.content, m.filters))
+		if len(msg.content) > 0 {
+			read := src.tail.lines
+			src.appendContent(msg.content)
+			src.tail.offset += int64(len(msg.content))
+
+			cmds = append(cmds, m.refreshPanes(src, src.tail.lines-read)...)
+		}
+
+		// Keep reading until the producer is done
+		if msg.err != nil {
+			src.done = true
+			if src.command != nil && src.command.finished() {
+				cmds = append(cmds, waitCommand(src.command.cmd))
+			}
+		} else {
+			cmds = append(cmds, readStream(src.id, src.stream))
+		}
+
+	case archivesReadMsg:
+		src := m.sources[msg.source]
+		src.content = append(slices.Clip(msg.content), src.content[len(src.archived):]...)
+		src.archived = msg.content
+		src.trim()
+
+		cmds = append(cmds, m.refreshPanes(src, 0)...)
+
+	case commandStartMsg:
+		m.command.start()
+		cmds = append(cmds, m.command.reads())
+
+	case commandExitedMsg:
+		if c := m.command; c != nil && msg.cmd == c.cmd {
+			c.exited, c.exitCode, c.exitErr = true, msg.code, msg.err
+		}
 
 	case fileGoneMsg:
-		m.fileExists = false
-		m.viewport.SetContent("")
+		src := m.sources[msg.source]
+		src.fileExists = false
+
+		// Hang on to what we know about the old file either way, so a replacement gets treated as a rotation
+		if !m.options.KeepRotated {
+			src.startOver(notRotated)
+
+			cmds = append(cmds, m.refreshPanes(src, 0)...)
+		}
+
+		cmds = append(cmds, src.finishCheck())
 
 	case fileErrorMsg:
-		content := "❌ Error reading file: " + msg.Error()
-		m.viewport.SetContent(content)
+		src := m.sources[msg.source]
+		m.showError(src, msg.err)
+
+		cmds = append(cmds, src.finishCheck())
+
+	case fileHugeMsg:
+		src := m.sources[msg.source]
+		src.prevFileInfo = msg.info
+		src.fileExists = true
+		src.startOver(msg.rotation)
+		src.huge = &hugeFile{index: msg.index, size: msg.info.Size()}
+
+		// The check carries on as indexing, so it isn't finished until that catches up
+		cmds = append(cmds, m.refreshPanes(src, 0)...)
+		cmds = append(cmds, src.indexMore())
+
+	case indexedMsg:
+		src := m.sources[msg.source]
+		h := src.huge
+		if h == nil || h.index != msg.index {
+			break
+		}
+		src.prevFileInfo = msg.info
+		src.parser = msg.parser
+
+		for _, p := range m.allPanes() {
+			if p.shows(src) && !p.following {
+				p.unseen += int(msg.lines - h.lines)
+			}
+		}
+
+		h.entries, h.lines, h.indexed, h.scanned, h.open = msg.entries, msg.lines, msg.indexed, msg.scanned, msg.open
+		h.midLine, h.pending, h.size = msg.midLine, msg.pending, msg.info.Size()
+		if h.indexing() {
+			cmds = append(cmds, src.indexMore())
+		} else {
+			cmds = append(cmds, src.finishCheck())
+		}
+		cmds = append(cmds, m.syncWindow(src))
+
+	case windowLoadedMsg:
+		src := m.sources[msg.source]
+		h := src.huge
+		if h == nil || h.index != msg.index {
+			break
+		}
+		h.loading = false
+
+		if msg.err != nil {
+			m.showError(src, msg.err)
+			break
+		}
+
+		h.first, h.window, h.readTo = msg.first, len(msg.records), msg.end
+		src.content = append(slices.Clip(src.archived), msg.records...)
+
+		for _, p := range m.allPanes() {
+			if !p.shows(src) {
+				continue
+			}
+			if msg.anchor.line >= 0 {
+				p.anchor, p.anchorAfter = msg.anchor, p.renders
+			}
+			if p.seek != scrollNone {
+				p.seekAfter = p.renders
+			}
+		}
+
+		cmds = append(cmds, m.refreshPanes(src, 0)...)
+		cmds = append(cmds, m.syncWindow(src))
 
 	case viewportUpdateMsg:
-		m.viewport.SetContentLines(msg)
+		p := msg.pane
+		if msg.render < p.shownRender {
+			break
+		}
+		p.shownRender = msg.render
+		p.viewLines = msg.lines
+		p.viewRecords = msg.records
+		p.search.matches = msg.matches
+		p.showLines()
+		p.applyAnchor(msg.render)
+		cmds = append(cmds, p.keepSeeking(msg.render)...)
 
 	case tickMsg:
-		cmds = append(cmds, checkFile(m.filename))
-		cmds = append(cmds, tickCmd())
+		m.polling = false
+		for _, src := range m.sources {
+			if src.needsPolling() {
+				cmds = append(cmds, src.check())
+			}
+		}
+		cmds = append(cmds, m.startPolling())
+
+	case safetyNetMsg:
+		for _, src := range m.sources {
+			if src.watcher != nil {
+				cmds = append(cmds, src.check())
+			}
+		}
+		cmds = append(cmds, safetyNetCmd())
+
+	case fileChangedMsg:
+		src := m.sources[msg.source]
+		cmds = append(cmds, src.check())
+		cmds = append(cmds, waitForChange(src.id, src.watcher))
+
+	case watcherStartedMsg:
+		src := m.sources[msg.source]
+		src.watcher = msg.watcher
+		cmds = append(cmds, waitForChange(src.id, src.watcher))
+
+	case watcherFailedMsg:
+		// Fall back to polling the file
+		m.sources[msg.source].watcher = nil
+		cmds = append(cmds, m.startPolling())
 	}
 
 	// Handle keyboard and mouse events in the viewport
@@ -252,62 +677,349 @@ func (m model) View() string {
 		return "\n  Initializing..."
 	}
 
-	return fmt.Sprintf("%s\n%s\n%s", m.Header(), m.viewport.View(), m.Footer())
+	return fmt.Sprintf("%s\n%s\n%s", m.Header(), m.viewPanes(), m.Footer())
+}
+
+// sourceTags renders the tag for each source's records, padded to line up. There aren't any with only one source
+func (m model) sourceTags() []string {
+	if len(m.sources) < 2 {
+		return nil
+	}
+
+	width := 0
+	for _, src := range m.sources {
+		width = max(width, lipgloss.Width(src.tag()))
+	}
+
+	tags := make([]string, len(m.sources))
+	for i, src := range m.sources {
+		tags[i] = src.tagStyle().Render(fmt.Sprintf("%-*s", width, src.tag()))
+	}
+
+	return tags
+}
+
+// quit stops campfire, along with the command it's running, if there is one
+func (m *model) quit() (tea.Model, tea.Cmd) {
+	m.Stop()
+	return m, tea.Quit
+}
+
+// Stop kills the program campfire was asked to run, if it's running. Quitting does this already,
+// but it needs doing by hand if the BubbleTea program ends some other way
+func (m *model) Stop() {
+	if m.command != nil {
+		m.command.stop()
+	}
+}
+
+// focusInput focuses the text input, to edit the filter or to type a new exclusion or search
+func (m *model) focusInput(mode inputMode) {
+	m.textActive = true
+	m.inputMode = mode
+	switch mode {
+	case excludeInput:
+		m.textInput.SetValue("")
+	case searchInput:
+		m.textInput.SetValue(m.search.text)
+		m.textInput.Placeholder = "<text>"
+	case gotoInput:
+		m.textInput.SetValue("")
+		m.textInput.Placeholder = "<line number or time>"
+	case unexcludeInput:
+		m.textInput.SetValue("")
+		m.textInput.Placeholder = "<number, or enter for the last>"
+	}
+	m.textInput.Focus()
+
+	m.keys.FocusFilter.SetEnabled(false)
+	m.keys.NoFocusClearFilter.SetEnabled(false)
+	m.keys.AddExclusion.SetEnabled(false)
+	m.keys.RemoveExclusion.SetEnabled(false)
+	m.keys.Search.SetEnabled(false)
+	m.keys.GoTo.SetEnabled(false)
+	m.keys.NextMatch.SetEnabled(false)
+	m.keys.PrevMatch.SetEnabled(false)
+	m.keys.FocusedClearFilter.SetEnabled(true)
+	m.keys.SaveFilter.SetEnabled(true)
+}
+
+// removeExclusion takes off the exclusion with the number shown on its chip, or the last one if there's no number
+func (m *model) removeExclusion(number string) error {
+	count := len(m.filters.Exclusions())
+	if count == 0 {
+		return nil
+	}
+
+	n := count
+	if strings.TrimSpace(number) != "" {
+		var err error
+		if n, err = strconv.Atoi(strings.TrimSpace(number)); err != nil || n < 1 || n > count {
+			return fmt.Errorf("expected a number from 1 to %d", count)
+		}
+	}
+
+	m.filters.RemoveExclusion(n - 1)
+	m.shareExclusions()
+	return nil
+}
+
+// shareExclusions copies the focused pane's exclusions to every other pane, since they're for the whole session
+func (m *model) shareExclusions() {
+	texts := m.filters.Exclusions()
+	for _, p := range m.allPanes() {
+		if p != m.pane {
+			p.filters.SetExclusions(texts)
+		}
+	}
+}
+
+// blurInput leaves the text input, putting the filter text back in it if it was being used for something else
+func (m *model) blurInput() {
+	if m.inputMode != filterInput {
+		m.textInput.SetValue(m.filters.FilterText)
+		m.textInput.Placeholder = filterPlaceholder
+		m.inputMode = filterInput
+		m.inputErr = nil
+	}
+	m.textActive = false
+	m.textInput.Blur()
+
+	m.keys.FocusFilter.SetEnabled(true)
+	m.keys.NoFocusClearFilter.SetEnabled(true)
+	m.keys.AddExclusion.SetEnabled(true)
+	m.keys.RemoveExclusion.SetEnabled(true)
+	m.keys.Search.SetEnabled(true)
+	m.keys.GoTo.SetEnabled(true)
+	m.keys.NextMatch.SetEnabled(true)
+	m.keys.PrevMatch.SetEnabled(true)
+	m.keys.FocusedClearFilter.SetEnabled(false)
+	m.keys.SaveFilter.SetEnabled(false)
+}
+
+// resize fits the viewport in between the header and footer. Those can change height
+// along with their contents, not just when the window does, so this runs after key presses too
+func (m *model) resize() {
+	if !m.ready {
+		return
+	}
+
+	m.help.Width = m.width
+	m.textInput.SetWidth(m.filterInputWidth())
+
+	headerHeight := lipgloss.Height(m.Header())
+	footerHeight := lipgloss.Height(m.Footer())
+	verticalMarginHeight := headerHeight + footerHeight
+
+	m.layoutPanes(m.width, m.height-verticalMarginHeight)
+}
+
+// showError puts an error with a source in place of the logs, in each pane showing it
+func (m *model) showError(src *source, err error) {
+	content := "❌ Error reading " + src.filename + ": " + err.Error()
+	for _, p := range m.allPanes() {
+		if p.shows(src) {
+			p.viewport.SetContent(content)
+		}
+	}
+}
+
+// refreshPanes re-merges the content of every pane showing a source that changed, and redraws the ones on screen.
+// Panes that aren't following count the lines that came in, so they can say how many there are
+func (m *model) refreshPanes(src *source, added int) []tea.Cmd {
+	var cmds []tea.Cmd
+	visible := m.visiblePanes()
+
+	for _, p := range m.allPanes() {
+		if !p.shows(src) {
+			continue
+		}
+
+		if !p.following {
+			p.unseen += added
+		}
+		p.content = mergeSources(p.files)
+
+		if slices.Contains(visible, p) {
+			cmds = append(cmds, updateViewport(p))
+		}
+	}
+
+	return cmds
+}
+
+// startPolling starts ticking to poll the files, unless it's already going or every file has a working watcher
+func (m *model) startPolling() tea.Cmd {
+	if m.polling || !slices.ContainsFunc(m.sources, (*source).needsPolling) {
+		return nil
+	}
+
+	m.polling = true
+	return tickCmd(m.options.PollInterval)
 }
 
 // ~~ Commands ~~
 
 // tickCmd will send the same tick on a constant cadence
-func tickCmd() tea.Cmd {
-	return tea.Tick(tickRate, func(t time.Time) tea.Msg {
+func tickCmd(interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
-// checkFile checks the current state of the file, returning a corresponding message
-func checkFile(name string) tea.Cmd {
+// safetyNetCmd sends a tick every so often to check the files that have watchers
+func safetyNetCmd() tea.Cmd {
+	return tea.Tick(safetyNetInterval, func(t time.Time) tea.Msg {
+		return safetyNetMsg(t)
+	})
+}
+
+// check starts reading the file, unless a read is already in flight. In that case another
+// check is queued up for when it finishes, since reading twice at once could duplicate lines
+func (s *source) check() tea.Cmd {
+	// Streams don't need checking, they send whatever comes in
+	if s.stream != nil || s.command != nil {
+		return nil
+	}
+
+	if s.reading {
+		s.recheck = true
+		return nil
+	}
+
+	s.reading = true
+	if s.huge != nil {
+		return s.indexMore()
+	}
+
+	return checkFile(s.id, s.filename, s.prevFileInfo, s.tail.offset, s.options.LazyThreshold)
+}
+
+// finishCheck marks the in-flight read as done, and starts any check that got queued up behind it.
+// It needs to be called after the read's results are applied, so the next check starts from there
+func (s *source) finishCheck() tea.Cmd {
+	s.reading = false
+
+	if !s.recheck {
+		return nil
+	}
+
+	s.recheck = false
+	return s.check()
+}
+
+// checkFile checks the current state of one of the files, returning a corresponding message.
+// Only the bytes past offset are read, unless the file was replaced or truncated since prev.
+// Files starting out at lazyThreshold or bigger aren't read at all, they're indexed instead
+func checkFile(src int, name string, prev fs.FileInfo, offset int64, lazyThreshold int64) tea.Cmd {
 	return func() tea.Msg {
 		info, err := os.Stat(name)
 
 		// File doesn't exist
 		if os.IsNotExist(err) {
-			return fileGoneMsg{}
+			return fileGoneMsg{src}
 		}
 
 		// File exists but error trying to access it
 		if err != nil {
-			return fileErrorMsg(err)
+			return fileErrorMsg{src, err}
+		}
+
+		// A different file now lives at this path, or the old one got shorter, so start over
+		rotation := detectRotation(prev, info, offset)
+		reload := prev == nil || rotation != notRotated
+		if reload {
+			offset = 0
+		} else if info.Size() == offset {
+			return fileExistsMsg{source: src, info: info, offset: offset}
 		}
 
 		// Otherwise, open file
 		file, err := os.Open(name)
 		if err != nil {
-			return fileErrorMsg(err)
+			return fileErrorMsg{src, err}
 		}
 
 		// Can close the file at the end of this since we'll extract all the content prior
 		defer file.Close()
 
-		// Grab all the content, return it in a message
+		// Compressed files can't be picked up part way through, so they're read whole whenever they change
+		header := make([]byte, 4)
+		n, _ := file.ReadAt(header, 0)
+		if c := detectCompression(name, header[:n]); c != noCompression {
+			content, err := decompress(c, file)
+			if err != nil {
+				return fileErrorMsg{src, err}
+			}
+
+			return fileExistsMsg{source: src, content: content, offset: info.Size(), info: info, reload: true, rotation: rotation}
+		}
+
+		if reload && lazyThreshold > 0 && info.Size() >= lazyThreshold {
+			index, err := newIndexFile()
+			if err != nil {
+				return fileErrorMsg{src, err}
+			}
+
+			return fileHugeMsg{source: src, info: info, rotation: rotation, index: index}
+		}
+
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return fileErrorMsg{src, err}
+		}
+
+		// Grab everything past the offset, return it in a message
 		content, err := io.ReadAll(file)
 		if err != nil {
-			return fileErrorMsg(err)
+			return fileErrorMsg{src, err}
 		}
 
-		return fileExistsMsg{content: content, info: info}
+		return fileExistsMsg{source: src, content: content, offset: offset + int64(len(content)), info: info, reload: reload, rotation: rotation}
 	}
 }
 
-func updateViewport(content []LogMessage, filters Filters) tea.Cmd {
+// updateViewport renders the records a pane's filters let through, off of the main loop.
+// What it needs is copied first, since the pane can change while that's happening
+func updateViewport(p *pane) tea.Cmd {
+	content, filters, render := p.content, p.filters, p.render
+	p.renders++
+	generation := p.renders
+
 	return func() tea.Msg {
 		var outContent []string
+		var records []viewRecord
 
-		for _, msg := range content {
-			if filters.IncludeMessage(msg) {
-				outContent = append(outContent, msg.String())
+		prev := -1
+		for i, shown := range filters.Select(content) {
+			if shown == hiddenMessage {
+				continue
 			}
+
+			// Like grep, mark where messages were skipped between groups of context
+			if filters.Context > 0 && prev >= 0 && i > prev+1 {
+				outContent = append(outContent, contextSeparatorStyle.Render("      --"))
+			}
+			prev = i
+
+			if !content[i].marker {
+				records = append(records, viewRecord{content[i].index, content[i].timestamp, len(outContent)})
+			}
+
+			lines := strings.Split(content[i].Render(render), "\n")
+			if shown == contextMessage {
+				for j, line := range lines {
+					lines[j] = contextStyle.Render(ansi.Strip(line))
+				}
+			}
+
+			outContent = append(outContent, lines...)
+		}
+
+		var matches []searchMatch
+		if render.search != nil {
+			outContent, matches = highlightMatches(outContent, render.search)
 		}
 
-		return viewportUpdateMsg(outContent)
+		return viewportUpdateMsg{p, generation, outContent, records, matches}
 	}
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// charmPattern matches the text format of charmbracelet/log: an optional timestamp, a four letter level,
+// then the message. Timestamps can use any layout, so anything made of digits and separators is allowed
+var charmPattern = regexp.MustCompile(`^(?:([0-9/:.\-+TZ ]+(?:AM|PM)?) )?(DEBU|INFO|WARN|ERRO|FATA)(?: (.*))?$`)
+
+// charmParser reads lines written by charmbracelet/log, i.e. `03:04:05PM INFO message key=value`
+type charmParser struct{}
+
+func (charmParser) Name() string { return "charm" }
+
+func (charmParser) Detect(line string) bool {
+	return charmPattern.MatchString(line)
+}
+
+func (charmParser) Parse(text string) (Record, bool) {
+	match := charmPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Record{}, false
+	}
+
+	message, fields := splitTrailingFields(match[3])
+
+	return Record{
+		Level:   levelFromName(match[2]),
+		Time:    strings.TrimSpace(match[1]),
+		Message: message,
+		Fields:  fields,
+	}, true
+}
+
+// splitTrailingFields separates a message from the logfmt key=value pairs written after it
+func splitTrailingFields(text string) (string, []Field) {
+	for i := range len(text) {
+		// Fields can only start at the beginning of a word
+		if i > 0 && text[i-1] != ' ' {
+			continue
+		}
+
+		if pairs, ok := parseLogfmtPairs(text[i:]); ok && len(pairs) > 0 {
+			return strings.TrimSpace(text[:i]), pairs
+		}
+	}
+
+	return text, nil
+}
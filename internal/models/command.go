@@ -0,0 +1,157 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// command is a program campfire runs itself, with its stdout and stderr shown as two sources
+type command struct {
+	args   []string
+	stdout *source
+	stderr *source
+
+	cmd      *exec.Cmd
+	startErr error // Why the program couldn't be started, if it couldn't
+
+	exited   bool
+	exitCode int   // Only meaningful once it's exited. -1 if it was killed by a signal
+	exitErr  error // Why waiting on it failed, if it wasn't just a non-zero exit
+}
+
+// newCommand sets up sources for a program's output, with ids following on from the files'
+func newCommand(args []string, firstID int, opts Options) *command {
+	c := &command{args: args}
+
+	c.stdout = &source{id: firstID, filename: "stdout", options: opts, parser: opts.Parser, command: c}
+	c.stderr = &source{id: firstID + 1, filename: "stderr", options: opts, parser: opts.Parser, command: c}
+
+	return c
+}
+
+// sources gets the command's stdout and stderr sources, in that order
+func (c *command) sources() []*source {
+	return []*source{c.stdout, c.stderr}
+}
+
+// String shows the command line, the way it'd be typed
+func (c *command) String() string {
+	return strings.Join(c.args, " ")
+}
+
+// start runs the program, with fresh streams reading its output. Output from any earlier run is cleared
+func (c *command) start() {
+	c.exited, c.exitCode, c.exitErr, c.startErr = false, 0, nil, nil
+
+	for _, src := range c.sources() {
+		src.startOver(notRotated)
+		src.stream, src.done = nil, true
+	}
+
+	c.cmd = exec.Command(c.args[0], c.args[1:]...)
+	newProcessGroup(c.cmd)
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		c.startErr = err
+		return
+	}
+	stderr, err := c.cmd.StderrPipe()
+	if err != nil {
+		c.startErr = err
+		return
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		c.startErr = err
+		return
+	}
+
+	c.stdout.stream, c.stdout.done = newStream(stdout), false
+	c.stderr.stream, c.stderr.done = newStream(stderr), false
+}
+
+// restart stops the program if it's still going, and starts it again. A marker notes when that happened
+func (c *command) restart() {
+	c.stop()
+	c.start()
+
+	c.stdout.content = append(c.stdout.content, c.stdout.marker("restarted at "+time.Now().Format("15:04")))
+}
+
+// stop kills the program along with anything it started, and stops reading its output. Once it's
+// exited, its process group id could belong to something else by now, so there's nothing to kill
+func (c *command) stop() {
+	if c.cmd == nil || c.cmd.Process == nil {
+		return
+	}
+
+	if !c.exited {
+		killProcessGroup(c.cmd)
+	}
+
+	// Once its output has ended, the old process is already being waited on. Otherwise nothing else
+	// is going to wait on it now, so it's reaped in the background
+	if !c.finished() {
+		go c.cmd.Wait()
+	}
+
+	for _, src := range c.sources() {
+		if src.stream != nil {
+			src.stream.close()
+		}
+	}
+}
+
+// reads starts reading both of the program's outputs
+func (c *command) reads() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, src := range c.sources() {
+		if src.stream != nil {
+			cmds = append(cmds, readStream(src.id, src.stream))
+		}
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// finished reports whether both of the program's outputs have been read to the end, so it can be waited on
+func (c *command) finished() bool {
+	return c.stdout.done && c.stderr.done
+}
+
+// waitCommand waits for a run of the program to exit. That has to wait until its output has all
+// been read, since waiting closes the pipes
+func waitCommand(cmd *exec.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		err := cmd.Wait()
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return commandExitedMsg{cmd: cmd, code: exitErr.ExitCode()}
+		}
+
+		return commandExitedMsg{cmd: cmd, err: err}
+	}
+}
+
+// status describes whether the program is still running, or how it exited
+func (c *command) status() string {
+	switch {
+	case c.startErr != nil:
+		return fmt.Sprintf("couldn't start: %v", c.startErr)
+	case !c.exited:
+		return "running"
+	case c.exitErr != nil:
+		return fmt.Sprintf("exited: %v", c.exitErr)
+	case c.exitCode < 0:
+		return "killed"
+	}
+
+	return fmt.Sprintf("exited %d", c.exitCode)
+}
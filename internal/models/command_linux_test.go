@@ -0,0 +1,102 @@
+package models
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStopKillsWholeCommand(t *testing.T) {
+	// The shell starts a child of its own, which has to go down along with it
+	c := newCommand([]string{"sh", "-c", "sleep 60 & echo $!; wait"}, 0, Options{})
+	c.start()
+	if c.startErr != nil {
+		t.Fatalf("couldn't start: %v", c.startErr)
+	}
+
+	chunk, ok := <-c.stdout.stream.chunks
+	if !ok {
+		t.Fatal("no output from the command")
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(chunk)))
+	if err != nil {
+		t.Fatalf("couldn't read the child's pid from %q", chunk)
+	}
+
+	c.stop()
+
+	// Nothing may reap it straight away, so a zombie counts as dead
+	deadline := time.Now().Add(5 * time.Second)
+	for alive(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("child %d still running after stop", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCommandStartsOnceRunning(t *testing.T) {
+	m := NewModel(nil, Options{Command: []string{"sh", "-c", "sleep 60"}})
+	if m.command.cmd != nil {
+		t.Fatal("the command was started before the program was running")
+	}
+
+	m.Update(commandStartMsg{})
+	if m.command.cmd == nil || m.command.cmd.Process == nil {
+		t.Fatalf("the command didn't start: %v", m.command.startErr)
+	}
+	pid := m.command.cmd.Process.Pid
+
+	m.Stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for alive(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("command %d still running after Stop", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClosedStreamStopsReading(t *testing.T) {
+	s := newStream(endless{})
+
+	// Once the queue fills up, the reader is stuck until something takes a chunk or the stream is closed
+	for len(s.chunks) < cap(s.chunks) {
+		time.Sleep(time.Millisecond)
+	}
+	s.close()
+	s.close()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-s.chunks:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("stream kept reading after it was closed")
+		}
+	}
+}
+
+// endless is a pipe that always has more to read
+type endless struct{}
+
+func (endless) Read(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// alive reports whether a process is still running, going by /proc
+func alive(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+
+	// The state comes after the command name, which is in parentheses
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package models
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// newProcessGroup starts the program in a process group of its own, so anything it starts can be stopped along with it
+func newProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the program and everything else in its process group, like the server behind `go run` or `sh -c`
+func killProcessGroup(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
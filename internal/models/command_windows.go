@@ -0,0 +1,13 @@
+package models
+
+import (
+	"os/exec"
+)
+
+// newProcessGroup does nothing on Windows, which doesn't have process groups like Unix does
+func newProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills just the program on Windows, since it wasn't given a process group
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compression is how a file is compressed, if it is
+type compression int
+
+const (
+	noCompression compression = iota
+	gzipCompression
+	zstdCompression
+	bzip2Compression
+)
+
+// compressionMagic is how each kind of compressed file starts
+var compressionMagic = map[compression][]byte{
+	gzipCompression:  {0x1f, 0x8b},
+	zstdCompression:  {0x28, 0xb5, 0x2f, 0xfd},
+	bzip2Compression: []byte("BZh"),
+}
+
+// compressionExtensions are what compressed files are usually named with, for when there's not enough of one to go by yet
+var compressionExtensions = map[string]compression{
+	".gz":  gzipCompression,
+	".zst": zstdCompression,
+	".bz2": bzip2Compression,
+}
+
+// detectCompression works out how a file is compressed from the bytes it starts with, or else from its extension
+func detectCompression(name string, header []byte) compression {
+	for c, magic := range compressionMagic {
+		if bytes.HasPrefix(header, magic) {
+			return c
+		}
+	}
+
+	if len(header) < len(compressionMagic[zstdCompression]) {
+		return compressionExtensions[filepath.Ext(name)]
+	}
+
+	return noCompression
+}
+
+// decompress reads all of a compressed file. One that ends too soon, like an archive that's only just been
+// rotated and is still empty or being written, is read as far as it goes rather than being an error
+func decompress(c compression, r io.Reader) ([]byte, error) {
+	content, err := decompressAll(c, r)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return content, nil
+	}
+
+	return content, err
+}
+
+// decompressAll reads all of a compressed file, however it's compressed
+func decompressAll(c compression, r io.Reader) ([]byte, error) {
+	switch c {
+	case gzipCompression:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		return io.ReadAll(gz)
+	case zstdCompression:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		return io.ReadAll(zr)
+	case bzip2Compression:
+		return io.ReadAll(bzip2.NewReader(r))
+	}
+
+	return io.ReadAll(r)
+}
+
+// readLogFile reads a whole file, decompressing it if needed
+func readLogFile(name string) ([]byte, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return decompress(detectCompression(name, content), bytes.NewReader(content))
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestReadLogFile(t *testing.T) {
+	const text = "first line\nsecond line\nthird line\n"
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(text))
+	gw.Close()
+
+	var zs bytes.Buffer
+	zw, _ := zstd.NewWriter(&zs)
+	zw.Write([]byte(text))
+	zw.Close()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		partial bool // Whether what's read only has to be the start of want
+	}{
+		{"app.log", text, text, false},
+		{"app.log.1", text, text, false},
+		{"app.log.1.gz", gz.String(), text, false},
+		{"app.log.1.zst", zs.String(), text, false},
+
+		// Freshly rotated archives that haven't been written yet
+		{"empty.log.1.gz", "", "", false},
+		{"empty.log.1.zst", "", "", false},
+		{"empty.log.1.bz2", "", "", false},
+		{"short.log.1.gz", gz.String()[:2], "", false},
+		{"short.log.1.zst", zs.String()[:3], "", false},
+		{"short.log.1.bz2", "BZ", "", false},
+
+		// Archives that are still being written
+		{"truncated.log.1.gz", gz.String()[:gz.Len()/2], text, true},
+		{"truncated.log.1.zst", zs.String()[:zs.Len()-4], text, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), tt.name)
+			writeFile(t, name, tt.content)
+
+			got, err := readLogFile(name)
+			if err != nil {
+				t.Fatalf("readLogFile() failed: %v", err)
+			}
+			if tt.partial && !bytes.HasPrefix([]byte(tt.want), got) || !tt.partial && string(got) != tt.want {
+				t.Errorf("readLogFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Filters struct {
+	hidden        uint64 // Levels whose messages are hidden, one bit per LogLevel
+	hiddenSources uint64 // Files whose messages are hidden, one bit per source
+
+	// MinSeverity hides every level less severe than it, on top of the per-level toggles, as long as
+	// Threshold is set. OTHER has no severity, so it's hidden whenever there's a threshold
+	MinSeverity int
+	Threshold   bool
+
+	// Since and Until hide messages logged outside of them, if they're set. Window does the same
+	// for messages older than it, as of when the filters are applied. Messages without timestamps
+	// are hidden while any of them are set, since there's no telling when they're from
+	Since, Until time.Time
+	Window       time.Duration
+
+	// Context is how many records to show before and after each one that gets through, even if they wouldn't
+	Context int
+
+	// FilterText is a query that shown messages have to match, see parseQuery. If it starts with `/`,
+	// the rest is a regex instead. Use SetFilterText to change it, so it gets compiled
+	FilterText string
+	IgnoreCase bool
+
+	matcher   textMatcher // Compiled from the filter text
+	FilterErr error       // Why the filter text couldn't be parsed or compiled, if it couldn't
+
+	// Messages matching any of these are hidden, whatever else lets them through. The slice is
+	// replaced rather than changed in place, since copies of the filters get used in the background
+	exclusions []exclusion
+}
+
+// exclusion is a query or regex whose matches get hidden, like health checks or heartbeats
+type exclusion struct {
+	text    string
+	matcher textMatcher
+}
+
+// textMatcher is filter text that's been parsed as a query or compiled as a regex
+type textMatcher struct {
+	query   queryNode
+	pattern *regexp.Regexp
+}
+
+// compileMatcher parses text as a query, or as a regex if it starts with `/`
+func compileMatcher(text string, ignoreCase bool) (textMatcher, error) {
+	expr, isRegex := strings.CutPrefix(text, "/")
+	if !isRegex {
+		query, err := parseQuery(text)
+		return textMatcher{query: query}, err
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		// Take off the prefix that's on every regex error, since it's shown right next to the filter anyway
+		return textMatcher{}, errors.New(strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+	}
+
+	// The flag is only added once the expression is known to be valid, so it never shows up in errors
+	if ignoreCase {
+		pattern = regexp.MustCompile("(?i)" + expr)
+	}
+
+	return textMatcher{pattern: pattern}, nil
+}
+
+// empty reports whether the matcher has nothing to match against, like for blank filter text
+func (t textMatcher) empty() bool {
+	return t.query == nil && t.pattern == nil
+}
+
+// matches reports whether a message matches. An empty matcher matches everything
+func (t textMatcher) matches(msg LogMessage, ignoreCase bool) bool {
+	switch {
+	case t.pattern != nil:
+		return t.pattern.MatchString(msg.message)
+	case t.query != nil:
+		return t.query.matches(msg, ignoreCase)
+	}
+
+	return true
+}
+
+// SetFilterText changes the filter text, parsing it as a query or compiling it as a regex.
+// If that fails, the error is kept in FilterErr and nothing gets filtered out by text
+func (f *Filters) SetFilterText(text string) {
+	f.FilterText = text
+	f.matcher, f.FilterErr = compileMatcher(text, f.IgnoreCase)
+}
+
+// ToggleIgnoreCase switches between case sensitive and insensitive matching of the filter text and exclusions
+func (f *Filters) ToggleIgnoreCase() {
+	f.IgnoreCase = !f.IgnoreCase
+	f.SetFilterText(f.FilterText)
+
+	// Exclusions were valid when they were added, and ignoring case doesn't change that
+	recompiled := make([]exclusion, len(f.exclusions))
+	for i, e := range f.exclusions {
+		matcher, _ := compileMatcher(e.text, f.IgnoreCase)
+		recompiled[i] = exclusion{e.text, matcher}
+	}
+	f.exclusions = recompiled
+}
+
+// IsRegex reports whether the filter text is a regex rather than a query
+func (f Filters) IsRegex() bool {
+	return strings.HasPrefix(f.FilterText, "/")
+}
+
+// matchesText reports whether a message passes the text filter
+func (f Filters) matchesText(msg LogMessage) bool {
+	return f.FilterErr != nil || f.matcher.matches(msg, f.IgnoreCase)
+}
+
+// AddExclusion hides messages matching a query or regex, written the same way as the filter text
+func (f *Filters) AddExclusion(text string) error {
+	matcher, err := compileMatcher(text, f.IgnoreCase)
+	if err != nil {
+		return err
+	}
+	if matcher.empty() {
+		return nil
+	}
+
+	f.exclusions = append(slices.Clip(f.exclusions), exclusion{text, matcher})
+	return nil
+}
+
+// RemoveExclusion shows the messages hidden by the i-th exclusion, counting from 0, again
+func (f *Filters) RemoveExclusion(i int) {
+	if i >= 0 && i < len(f.exclusions) {
+		f.exclusions = slices.Delete(slices.Clone(f.exclusions), i, i+1)
+	}
+}
+
+// SetExclusions replaces the exclusions with ones with the given text, like when they're shared from other filters.
+// Any that can't be compiled are left out
+func (f *Filters) SetExclusions(texts []string) {
+	exclusions := make([]exclusion, 0, len(texts))
+	for _, text := range texts {
+		if matcher, err := compileMatcher(text, f.IgnoreCase); err == nil && !matcher.empty() {
+			exclusions = append(exclusions, exclusion{text, matcher})
+		}
+	}
+	f.exclusions = exclusions
+}
+
+// Exclusions gets the text of each exclusion, oldest first
+func (f Filters) Exclusions() []string {
+	texts := make([]string, len(f.exclusions))
+	for i, e := range f.exclusions {
+		texts[i] = e.text
+	}
+
+	return texts
+}
+
+// excluded reports whether any exclusion matches a message
+func (f Filters) excluded(msg LogMessage) bool {
+	for _, e := range f.exclusions {
+		if e.matcher.matches(msg, f.IgnoreCase) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ShowsLevel reports whether messages of the given level are shown
+func (f Filters) ShowsLevel(l LogLevel) bool {
+	return f.hidden&(1<<l) == 0
+}
+
+// ToggleLevel shows messages of the given level if they were hidden, and hides them otherwise
+func (f *Filters) ToggleLevel(l LogLevel) {
+	f.hidden ^= 1 << l
+}
+
+func (f Filters) IncludeMessage(msg LogMessage) bool {
+	if !f.ShowsSource(msg.source) {
+		return false
+	}
+
+	// Markers explain what happened to the file, so they're never hidden on their own
+	if msg.marker {
+		return true
+	}
+
+	if !f.matchesText(msg) || f.excluded(msg) {
+		return false
+	}
+
+	if f.BelowThreshold(msg.level) {
+		return false
+	}
+
+	if f.HasTimeRange() && !f.inTimeRange(msg.timestamp, time.Now()) {
+		return false
+	}
+
+	return f.ShowsLevel(msg.level)
+}
+
+// ShowsSource reports whether messages from the given source are shown
+func (f Filters) ShowsSource(source int) bool {
+	return f.hiddenSources&(1<<source) == 0
+}
+
+// ToggleSource shows messages from the given source if they were hidden, and hides them otherwise
+func (f *Filters) ToggleSource(source int) {
+	f.hiddenSources ^= 1 << source
+}
+
+// HasTimeRange reports whether messages are being filtered by when they were logged
+func (f Filters) HasTimeRange() bool {
+	return !f.Since.IsZero() || !f.Until.IsZero() || f.Window > 0
+}
+
+// inTimeRange reports whether a timestamp is inside the time range, with the window ending at now
+func (f Filters) inTimeRange(t, now time.Time) bool {
+	if t.IsZero() {
+		return false
+	}
+
+	if !f.Since.IsZero() && compareTimes(t, f.Since) < 0 {
+		return false
+	}
+	if !f.Until.IsZero() && compareTimes(t, f.Until) > 0 {
+		return false
+	}
+	if f.Window > 0 && timeSince(t, now) > f.Window {
+		return false
+	}
+
+	return true
+}
+
+// timeWindows are what CycleWindow steps through, after having no window
+var timeWindows = []time.Duration{5 * time.Minute, 15 * time.Minute, time.Hour, 24 * time.Hour}
+
+// CycleWindow steps the window through the last 5 minutes, 15 minutes, hour and day, then turns it off again
+func (f *Filters) CycleWindow() {
+	i := slices.Index(timeWindows, f.Window)
+	if i == len(timeWindows)-1 {
+		f.Window = 0
+		return
+	}
+
+	f.Window = timeWindows[i+1]
+}
+
+// shownAs is whether a message is shown, and why
+type shownAs int
+
+const (
+	hiddenMessage  shownAs = iota
+	matchedMessage         // It got through the filters
+	contextMessage         // It's near one that got through the filters
+)
+
+// Select decides how each message in content is shown, adding context around the ones that get through
+func (f Filters) Select(content []LogMessage) []shownAs {
+	shown := make([]shownAs, len(content))
+	contextUntil := -1 // Last index that's within context after a match
+
+	for i, msg := range content {
+		if !f.IncludeMessage(msg) {
+			if i <= contextUntil {
+				shown[i] = contextMessage
+			}
+			continue
+		}
+
+		shown[i] = matchedMessage
+
+		// Markers are always shown, but they aren't what anyone searched for, so they bring no context
+		if msg.marker {
+			continue
+		}
+
+		for j := max(i-f.Context, 0); j < i; j++ {
+			if shown[j] == hiddenMessage {
+				shown[j] = contextMessage
+			}
+		}
+		contextUntil = i + f.Context
+	}
+
+	return shown
+}
+
+// BelowThreshold reports whether a level is hidden by the threshold, whatever its toggle says
+func (f Filters) BelowThreshold(l LogLevel) bool {
+	return f.Threshold && (l == OtherLevel || l.Severity() < f.MinSeverity)
+}
+
+// MinLevel gets the least severe level that the threshold still lets through, if there's a threshold
+func (f Filters) MinLevel() (LogLevel, bool) {
+	if !f.Threshold {
+		return OtherLevel, false
+	}
+
+	for _, l := range levelsBySeverity() {
+		if l != OtherLevel && l.Severity() >= f.MinSeverity {
+			return l, true
+		}
+	}
+
+	return OtherLevel, true
+}
+
+// RaiseThreshold hides the least severe level that the threshold still lets through. The first
+// step only hides OTHER, by setting the threshold at the least severe level
+func (f *Filters) RaiseThreshold() {
+	ordered := levelsBySeverity()
+	ordered = ordered[:len(ordered)-1] // OTHER isn't one of the steps
+
+	if !f.Threshold {
+		f.Threshold = true
+		f.MinSeverity = ordered[0].Severity()
+		return
+	}
+
+	for _, l := range ordered {
+		if l.Severity() > f.MinSeverity {
+			f.MinSeverity = l.Severity()
+			return
+		}
+	}
+}
+
+// LowerThreshold lets the most severe level that the threshold hides through again.
+// Once nothing but OTHER is hidden, the threshold comes off
+func (f *Filters) LowerThreshold() {
+	if !f.Threshold {
+		return
+	}
+
+	ordered := levelsBySeverity()
+	ordered = ordered[:len(ordered)-1]
+
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if ordered[i].Severity() < f.MinSeverity {
+			f.MinSeverity = ordered[i].Severity()
+			return
+		}
+	}
+
+	f.Threshold = false
+}
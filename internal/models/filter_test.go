@@ -0,0 +1,154 @@
+package models
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+func TestThreshold(t *testing.T) {
+	var f Filters
+	if _, ok := f.MinLevel(); ok {
+		t.Fatal("new filters have a threshold")
+	}
+
+	// Raising steps through every level, with the first step only hiding OTHER
+	want := []LogLevel{TraceLevel, DebugLevel, InfoLevel, NoticeLevel, WarnLevel, ErrorLevel, CriticalLevel, FatalLevel, PanicLevel}
+	for _, level := range want {
+		f.RaiseThreshold()
+		if got, ok := f.MinLevel(); !ok || got != level {
+			t.Fatalf("raised threshold = %v (%t), want %v", got, ok, level)
+		}
+	}
+	f.RaiseThreshold()
+	if got, _ := f.MinLevel(); got != PanicLevel {
+		t.Errorf("raising past the top = %v, want it to stay at %v", got, PanicLevel)
+	}
+
+	for i := len(want) - 2; i >= 0; i-- {
+		f.LowerThreshold()
+		if got, ok := f.MinLevel(); !ok || got != want[i] {
+			t.Fatalf("lowered threshold = %v (%t), want %v", got, ok, want[i])
+		}
+	}
+	f.LowerThreshold()
+	if _, ok := f.MinLevel(); ok {
+		t.Error("lowering past the bottom left a threshold")
+	}
+}
+
+func TestThresholdHidesOther(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters Filters
+		level   LogLevel
+		want    bool
+	}{
+		{"no threshold, other", Filters{}, OtherLevel, true},
+		{"no threshold, trace", Filters{}, TraceLevel, true},
+		{"zero severity without threshold", Filters{MinSeverity: 0}, TraceLevel, true},
+		{"at trace, other", Filters{MinSeverity: 10, Threshold: true}, OtherLevel, false},
+		{"at trace, trace", Filters{MinSeverity: 10, Threshold: true}, TraceLevel, true},
+		{"at warn, info", Filters{MinSeverity: 40, Threshold: true}, InfoLevel, false},
+		{"at warn, warn", Filters{MinSeverity: 40, Threshold: true}, WarnLevel, true},
+		{"at warn, error", Filters{MinSeverity: 40, Threshold: true}, ErrorLevel, true},
+		{"at warn, other", Filters{MinSeverity: 40, Threshold: true}, OtherLevel, false},
+		{"at zero, other", Filters{MinSeverity: 0, Threshold: true}, OtherLevel, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := LogMessage{level: tt.level, message: "hello"}
+			if got := tt.filters.IncludeMessage(msg); got != tt.want {
+				t.Errorf("IncludeMessage() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	// Markers are never hidden by the threshold
+	marker := NewMarkerMessage("log rotated")
+	if !(Filters{MinSeverity: 40, Threshold: true}).IncludeMessage(marker) {
+		t.Error("threshold hid a marker")
+	}
+}
+
+func TestRemoveExclusion(t *testing.T) {
+	var f Filters
+	for _, text := range []string{"health", "heartbeat", "/ping \\d+"} {
+		if err := f.AddExclusion(text); err != nil {
+			t.Fatalf("AddExclusion(%q) failed: %v", text, err)
+		}
+	}
+	shared := f.exclusions
+
+	f.RemoveExclusion(0)
+	if got := f.Exclusions(); !reflect.DeepEqual(got, []string{"heartbeat", "/ping \\d+"}) {
+		t.Errorf("after removing the first, exclusions = %q", got)
+	}
+	if shared[0].text != "health" {
+		t.Error("removing an exclusion changed the old slice, which could still be in use")
+	}
+
+	f.RemoveExclusion(5)
+	f.RemoveExclusion(-1)
+	if got := len(f.Exclusions()); got != 2 {
+		t.Errorf("removing out of range left %d exclusions, want 2", got)
+	}
+
+	msg := LogMessage{message: "ping 42", level: InfoLevel}
+	if f.IncludeMessage(msg) {
+		t.Error("the regex exclusion didn't hide its match")
+	}
+}
+
+func TestExclusionsShared(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	writeFile(t, a, "")
+	writeFile(t, b, "")
+
+	m := NewModel([]string{a, b}, Options{})
+	m.setLayout(tabsLayout)
+
+	m.focusInput(excludeInput)
+	m.textInput.SetValue("health")
+	m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+
+	for i, p := range m.allPanes() {
+		if got := p.filters.Exclusions(); !reflect.DeepEqual(got, []string{"health"}) {
+			t.Errorf("pane %d exclusions = %q, want the one added in the focused pane", i, got)
+		}
+	}
+
+	if err := m.removeExclusion("2"); err == nil {
+		t.Error("removing exclusion 2 of 1 worked, want an error")
+	}
+	if err := m.removeExclusion("1"); err != nil {
+		t.Fatalf("removing exclusion 1 failed: %v", err)
+	}
+	for i, p := range m.allPanes() {
+		if got := p.filters.Exclusions(); len(got) != 0 {
+			t.Errorf("pane %d still has exclusions %q", i, got)
+		}
+	}
+}
+
+func TestSelectMarkersBringNoContext(t *testing.T) {
+	var content []LogMessage
+	for i, text := range []string{"one", "two", "boom", "three", "four", "five", "six"} {
+		content = append(content, NewLogMessage(i, text, plainParser{}))
+	}
+	content = slices.Insert(content, 5, NewMarkerMessage("log rotated"))
+
+	f := Filters{Context: 1}
+	f.SetFilterText("boom")
+
+	// one two boom three four marker five six
+	want := []shownAs{hiddenMessage, contextMessage, matchedMessage, contextMessage, hiddenMessage, matchedMessage, hiddenMessage, hiddenMessage}
+	if got := f.Select(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Select = %v, want %v", got, want)
+	}
+}
@@ -2,34 +2,230 @@ package models
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss/v2"
 )
 
-const LevelFilterString = "[1] INFO %v | [2] WARN %v | [3] ERROR %v | [4] DEBUG %v| [5] FATAL %v"
+// minFilterWidth is the narrowest the filter input can get before it's moved below the level toggles
+const minFilterWidth = 20
 
 var visibleIcon = lipgloss.NewStyle().Foreground(lipgloss.Color("#a6da95")).Render("✔")
 var invisibleIcon = lipgloss.NewStyle().Foreground(lipgloss.Color("#ed8796")).Render("✘")
+var thresholdIcon = lipgloss.NewStyle().Foreground(statsColor).Render("↓")
 
 var borderStyle = lipgloss.NewStyle().AlignHorizontal(lipgloss.Center).Border(lipgloss.RoundedBorder()).PaddingLeft(1)
 
 // Footer prints the helptext and contact/repo info
 func (m model) Footer() string {
+	rows := m.toggleRows()
+	sepChar := ternary(m.stackFooter(), "\n", " | ")
 
-	infoIcon := ternary(m.filters.ShowInfo, visibleIcon, invisibleIcon)
-	warnIcon := ternary(m.filters.ShowWarn, visibleIcon, invisibleIcon)
-	errorIcon := ternary(m.filters.ShowError, visibleIcon, invisibleIcon)
-	debugIcon := ternary(m.filters.ShowDebug, visibleIcon, invisibleIcon)
-	fatalIcon := ternary(m.filters.ShowFatal, visibleIcon, invisibleIcon)
+	content := strings.Join(rows, "\n") + sepChar + m.textInput.View() + m.inputStatus()
+	if chips := m.exclusionRows(); len(chips) > 0 {
+		content += "\n" + strings.Join(chips, "\n")
+	}
 
-	// sepChar := ternary(m.width < lipgloss.Width(LevelFilterString)+lipgloss.Width(m.textInput.Prompt)*2, "\n", " | ")
+	levelFilter := borderStyle.Render(content)
 
-	levelFilter := borderStyle.Render(
-		fmt.Sprintf(LevelFilterString,
-			infoIcon, warnIcon, errorIcon, debugIcon, fatalIcon,
-		) + " | " + m.textInput.View())
+	return levelFilter + "\n" + lipgloss.PlaceHorizontal(m.width, lipgloss.Center, m.help.ShortHelpView(m.keys.ShortHelp()))
+}
 
-	// outContent = lipgloss.JoinHorizontal(lipgloss.Center, levelFilter, borderStyle.Render(m.textInput.View()))
+// filterPrompt labels the filter input with how its text gets matched
+func (m model) filterPrompt() string {
+	prompt := ternary(m.filters.IsRegex(), "Regex", "Query")
+	switch m.inputMode {
+	case excludeInput:
+		prompt = "Exclude"
+	case searchInput:
+		prompt = "Search"
+	case gotoInput:
+		return "Go to: "
+	case unexcludeInput:
+		return "Stop excluding #: "
+	}
+	if m.filters.IgnoreCase {
+		prompt += " (aA)"
+	}
 
-	return levelFilter + "\n" + lipgloss.PlaceHorizontal(m.width, lipgloss.Center, m.help.ShortHelpView(m.keys.ShortHelp()))
+	return prompt + ": "
+}
+
+// inputStatus is shown after the text input: any problem with what's in it, then how the search is going
+func (m model) inputStatus() string {
+	return m.filterError() + m.searchStatus()
+}
+
+// searchStatus shows which search match is selected out of how many, like "match 3/47"
+func (m model) searchStatus() string {
+	if m.search.pattern == nil {
+		return ""
+	}
+
+	status := "no matches"
+	if len(m.search.matches) > 0 {
+		status = fmt.Sprintf("match %d/%d", m.search.current+1, len(m.search.matches))
+	}
+
+	// The search text is only in the input while it's being typed
+	if m.inputMode != searchInput {
+		status = "/" + m.search.text + " " + status
+	}
+
+	return " " + statsStyle.Render(status)
+}
+
+// filterError explains why the filter text couldn't be used, if it couldn't
+func (m model) filterError() string {
+	err := m.filters.FilterErr
+	if m.inputMode != filterInput {
+		err = m.inputErr
+	}
+
+	if err == nil {
+		return ""
+	}
+
+	return " " + filterErrorStyle.Render("✘ "+err.Error())
+}
+
+// footerWidth gets how much room there is inside the footer's border
+func (m model) footerWidth() int {
+	return m.width - borderStyle.GetHorizontalFrameSize()
+}
+
+// toggleRows shows the source toggles, if there's more than one source, and then the level toggles
+func (m model) toggleRows() []string {
+	return append(m.sourceToggleRows(), m.levelToggleRows()...)
+}
+
+// sourceToggleRows shows the key, tag and visibility of each source, if the focused pane has more than one
+func (m model) sourceToggleRows() []string {
+	if m.sourceToggleCount() == 0 {
+		return nil
+	}
+
+	var toggles []string
+	for i, src := range m.sources {
+		icon := ternary(m.filters.ShowsSource(i), visibleIcon, invisibleIcon)
+		tag := src.tagStyle().Render(src.tag())
+
+		if i < len(m.keys.ToggleSources) {
+			toggles = append(toggles, fmt.Sprintf("[alt+%d] %s %v", i+1, tag, icon))
+		} else {
+			toggles = append(toggles, fmt.Sprintf("%s %v", tag, icon))
+		}
+	}
+
+	return m.wrapFooterRow(toggles, " | ")
+}
+
+// levelToggleRows shows the key, name and visibility of every level that can be toggled, least severe first.
+// They're split into as many rows as it takes to fit in the footer
+func (m model) levelToggleRows() []string {
+	var toggles []string
+
+	for _, level := range levelsBySeverity() {
+		if levels[level].Key == "" {
+			continue
+		}
+
+		// Levels under the threshold get their own icon, since their toggle doesn't matter right now
+		icon := ternary(m.filters.ShowsLevel(level), visibleIcon, invisibleIcon)
+		if m.filters.BelowThreshold(level) {
+			icon = thresholdIcon
+		}
+
+		toggles = append(toggles, fmt.Sprintf("[%s] %s %v", levels[level].Key, level.Style().Render(level.String()), icon))
+	}
+
+	threshold := "off"
+	if minLevel, ok := m.filters.MinLevel(); ok {
+		threshold = minLevel.Style().Render(minLevel.String()) + "+"
+	}
+	toggles = append(toggles, "[+/-] min: "+threshold)
+	toggles = append(toggles, "[t] time: "+m.timeRangeLabel())
+	toggles = append(toggles, fmt.Sprintf("[</>] context: %d", m.filters.Context))
+
+	return m.wrapFooterRow(toggles, " | ")
+}
+
+// exclusionRows shows each exclusion as a chip, so it's clear what's being hidden. They're numbered so a
+// particular one can be taken off with E
+func (m model) exclusionRows() []string {
+	exclusions := m.filters.Exclusions()
+	if len(exclusions) == 0 {
+		return nil
+	}
+
+	chips := []string{"[E] hiding:"}
+	for i, text := range exclusions {
+		chips = append(chips, fmt.Sprintf("%d %s %s", i+1, invisibleIcon, chipStyle.Render(text)))
+	}
+
+	return m.wrapFooterRow(chips, " ")
+}
+
+// wrapFooterRow joins items with sep, split into as many rows as it takes to fit them in the footer
+func (m model) wrapFooterRow(items []string, sep string) []string {
+	var rows []string
+	var row string
+
+	for _, item := range items {
+		switch {
+		case row == "":
+			row = item
+		case lipgloss.Width(row+sep+item) <= m.footerWidth():
+			row += sep + item
+		default:
+			rows = append(rows, row)
+			row = item
+		}
+	}
+
+	return append(rows, row)
+}
+
+// timeRangeLabel describes the time range filter, like "last 15m" or "since 10:04:00"
+func (m model) timeRangeLabel() string {
+	if !m.filters.HasTimeRange() {
+		return "all"
+	}
+
+	now := time.Now()
+	var parts []string
+	if m.filters.Window > 0 {
+		window := strings.TrimSuffix(strings.TrimSuffix(m.filters.Window.String(), "0s"), "0m")
+		parts = append(parts, "last "+window)
+	}
+	if !m.filters.Since.IsZero() {
+		parts = append(parts, "since "+formatTimeBound(m.filters.Since, now))
+	}
+	if !m.filters.Until.IsZero() {
+		parts = append(parts, "until "+formatTimeBound(m.filters.Until, now))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// stackFooter reports whether the filter input has to go below the level toggles to fit
+func (m model) stackFooter() bool {
+	rows := m.toggleRows()
+	if len(rows) > 1 {
+		return true
+	}
+
+	sideBySide := lipgloss.Width(rows[0]) + lipgloss.Width(" | ") + lipgloss.Width(m.textInput.Prompt) + lipgloss.Width(m.inputStatus())
+	return m.footerWidth()-sideBySide < minFilterWidth
+}
+
+// filterInputWidth gets how much room is left over for the filter input's text, leaving a cell for the cursor
+func (m model) filterInputWidth() int {
+	width := m.footerWidth() - lipgloss.Width(m.textInput.Prompt) - lipgloss.Width(m.inputStatus()) - 1
+	if !m.stackFooter() {
+		width -= lipgloss.Width(m.toggleRows()[0]) + lipgloss.Width(" | ")
+	}
+
+	return max(width, 0)
 }
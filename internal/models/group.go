@@ -0,0 +1,54 @@
+package models
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// recordStartPattern loosely matches the start of a typical log line: a date, a time, a syslog
+// style month and day, an opening brace of a JSON object, or a level name
+var recordStartPattern = regexp.MustCompile(
+	`^(\[?\d{4}-\d{2}-\d{2}|\[?\d{1,2}:\d{2}|\[?[A-Z][a-z]{2} +\d{1,2} |\{|\[?(?i:info|warn|erro|debu|fata|trace|panic|notice|crit))`,
+)
+
+// record collects the lines of a log message that's still being read. It's kept open,
+// since continuation lines for it could still show up later
+type record struct {
+	start   int // Line number of the record's first line
+	lines   []string
+	arrived time.Time // When the record's first line was read
+}
+
+// message turns the lines collected so far into a single LogMessage
+func (r record) message(parser Parser) LogMessage {
+	return NewLogMessage(r.start, strings.Join(r.lines, "\n"), parser)
+}
+
+// with returns a copy of the record with one more line on the end, leaving the original alone
+func (r record) with(line string) record {
+	r.lines = append(slices.Clip(r.lines), line)
+	return r
+}
+
+// continuesRecord reports whether line belongs to the record before it, like the body of a stack
+// trace, rather than starting a new record of its own
+func (s *source) continuesRecord(r *record, line string) bool {
+	if r == nil {
+		return false
+	}
+
+	if s.options.RecordStart != nil {
+		return !s.options.RecordStart.MatchString(line)
+	}
+
+	// Indented lines are pretty much always the body of whatever came before them
+	if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+		return true
+	}
+
+	// Otherwise, only records that look like proper log lines can be continued. If they
+	// didn't, every line of a file without timestamps or levels would get lumped together
+	return recordStartPattern.MatchString(r.lines[0]) && !recordStartPattern.MatchString(line)
+}
@@ -2,7 +2,10 @@ package models
 
 import (
 	"fmt"
+	"io"
+	"strings"
 
+	"github.com/charmbracelet/lipgloss/v2"
 	"github.com/dustin/go-humanize"
 )
 
@@ -11,20 +14,188 @@ func (m model) Header() string {
 	cContent := titleStyle.Render("Campfire")
 
 	rContent := ""
-	if m.fileExists {
-		filesize := humanize.Bytes(uint64(m.prevFileInfo.Size()))
+	if len(m.sources) == 1 {
+		src := m.sources[0]
+		switch {
+		case src.stream != nil:
+			rContent = fmt.Sprintf(
+				"%v %v",
+				fileNameStyle.Render(src.tag()),
+				fmt.Sprintf("(Read: %v)", humanize.Bytes(uint64(src.size()))),
+			)
+		case src.fileExists:
+			filesize := humanize.Bytes(uint64(src.size()))
 
+			rContent = fmt.Sprintf(
+				"%v %v",
+				fileNameStyle.Render(src.filename),
+				fmt.Sprintf("(Size: %v)", filesize),
+			)
+		default:
+			rContent = "File not found..."
+		}
+	} else if m.command != nil && len(m.sources) == 2 {
 		rContent = fmt.Sprintf(
 			"%v %v",
-			fileNameStyle.Render(m.filename),
-			fmt.Sprintf("(Size: %v)", filesize),
+			fileNameStyle.Render("$ "+m.command.String()),
+			fmt.Sprintf("(Read: %v)", humanize.Bytes(uint64(m.command.stdout.size()+m.command.stderr.size()))),
 		)
 	} else {
-		rContent = "File not found..."
+		rContent = m.sourcesSummary()
 	}
-	rContent = statsStyle.Render(rContent)
+	rContent = statsStyle.Render(rContent) + m.indexIndicator() + m.trimIndicator() + m.streamIndicator() + m.commandIndicator() + " " + m.followIndicator()
 
 	lContent := statsStyle.Italic(true).Render("https://github.com/daltonsw/campfire")
 
-	return align(m.width, lContent, cContent, rContent)
+	header := align(m.width, lContent, cContent, rContent)
+	if tabs := m.paneTabs(); tabs != "" {
+		header += "\n" + lipgloss.PlaceHorizontal(m.width, lipgloss.Center, tabs)
+	}
+
+	return header
+}
+
+// sourcesSummary counts the files and streams being shown, and how much there is of each. Streams, like
+// stdin or a command's output, are counted apart from files, since they never go missing and don't have a size
+func (m model) sourcesSummary() string {
+	var size, read int64
+	files, found, streams := 0, 0, 0
+	for _, src := range m.sources {
+		switch {
+		case src.isStream():
+			streams++
+			read += src.size()
+		case src.fileExists:
+			files++
+			found++
+			size += src.size()
+		default:
+			files++
+		}
+	}
+
+	var counts, amounts []string
+	if files > 0 {
+		count := countOf(files, "file")
+		if found < files {
+			count = fmt.Sprintf("%d of %s", found, count)
+		}
+		counts = append(counts, count)
+		amounts = append(amounts, "Size: "+humanize.Bytes(uint64(size)))
+	}
+	if streams > 0 {
+		counts = append(counts, countOf(streams, "stream"))
+		amounts = append(amounts, "Read: "+humanize.Bytes(uint64(read)))
+	}
+
+	return fmt.Sprintf(
+		"%v %v",
+		fileNameStyle.Render(strings.Join(counts, ", ")),
+		"("+strings.Join(amounts, ", ")+")",
+	)
+}
+
+// countOf writes out how many of something there are, like "1 file" or "3 files"
+func countOf(n int, noun string) string {
+	return fmt.Sprintf("%d %s", n, noun+ternary(n == 1, "", "s"))
+}
+
+// paneTabs names each file's pane, picking out the focused one, and shows the layout. There aren't any when merged
+func (m model) paneTabs() string {
+	if m.layout == mergedLayout {
+		return ""
+	}
+
+	var tabs []string
+	for _, p := range m.filePanes {
+		style := tabStyle
+		if p == m.pane {
+			style = activeTabStyle
+		}
+		tabs = append(tabs, style.Render(p.title()))
+	}
+
+	return strings.Join(tabs, tabStyle.Render(" │ ")) + " " + statsStyle.Render("["+m.layout.String()+"]")
+}
+
+// indexIndicator shows how far along indexing huge files is, while that's still going
+func (m model) indexIndicator() string {
+	var indexed, size int64
+	for _, src := range m.sources {
+		if src.huge != nil && src.huge.indexing() {
+			indexed += src.huge.scanned
+			size += src.huge.size
+		}
+	}
+
+	if size == 0 {
+		return ""
+	}
+
+	return " " + statsStyle.Render("indexing") + " " + m.progress.ViewAs(float64(indexed)/float64(size))
+}
+
+// trimIndicator says how many lines are being kept out of how many have been read, once old ones start getting dropped
+func (m model) trimIndicator() string {
+	evicted := 0
+	for _, src := range m.sources {
+		evicted += src.evicted
+	}
+
+	if evicted == 0 {
+		return ""
+	}
+
+	// Content is only this small to count through because there's a max
+	kept := 0
+	for _, src := range m.sources {
+		for _, msg := range src.content {
+			kept += msg.lineCount()
+		}
+	}
+
+	return " " + statsStyle.Render(fmt.Sprintf("showing last %d of %d lines", kept, kept+evicted))
+}
+
+// streamIndicator says when the producer piping logs into campfire has exited, or why reading from it failed
+func (m model) streamIndicator() string {
+	for _, src := range m.sources {
+		if src.stream == nil || !src.done || src.command != nil {
+			continue
+		}
+
+		if src.stream.err != io.EOF {
+			return " " + filterErrorStyle.Render("✘ "+src.tag()+": "+src.stream.err.Error())
+		}
+
+		return " " + eofStyle.Render("⏹ EOF reached")
+	}
+
+	return ""
+}
+
+// commandIndicator shows whether the command is still running, or its exit code once it's done
+func (m model) commandIndicator() string {
+	if m.command == nil {
+		return ""
+	}
+
+	status := m.command.status()
+	switch {
+	case m.command.startErr != nil, m.command.exitErr != nil, m.command.exited && m.command.exitCode != 0:
+		return " " + filterErrorStyle.Render("✘ "+status)
+	case m.command.exited:
+		return " " + eofStyle.Render("⏹ "+status)
+	}
+
+	return " " + followingStyle.Render("▶ "+status)
+}
+
+// followIndicator shows whether new lines are being followed, or how many have come in since that was paused
+func (m model) followIndicator() string {
+	if m.following {
+		return followingStyle.Render("● following")
+	}
+
+	return pausedStyle.Render(fmt.Sprintf("⏸ paused (+%d new)", m.unseen))
 }
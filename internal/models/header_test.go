@@ -0,0 +1,38 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestSourcesSummary(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, name, "")
+	info := stat(t, name)
+
+	cmd := &command{}
+	file := func(exists bool) *source { return &source{filename: name, fileExists: exists, prevFileInfo: info} }
+
+	tests := []struct {
+		name    string
+		sources []*source
+		want    string
+	}{
+		{"files", []*source{file(true), file(true)}, "2 files (Size: 0 B)"},
+		{"missing file", []*source{file(true), file(false)}, "1 of 2 files (Size: 0 B)"},
+		{"stdin and a file", []*source{file(true), {filename: StdinName}}, "1 file, 1 stream (Size: 0 B, Read: 0 B)"},
+		{"command and files", []*source{file(true), file(true), {command: cmd}, {command: cmd}}, "2 files, 2 streams (Size: 0 B, Read: 0 B)"},
+		{"command and stdin", []*source{{filename: StdinName}, {command: cmd}, {command: cmd}}, "3 streams (Read: 0 B)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{sources: tt.sources}
+			if got := ansi.Strip(m.sourcesSummary()); got != tt.want {
+				t.Errorf("sourcesSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
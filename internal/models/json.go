@@ -0,0 +1,66 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// jsonParser reads JSON objects, like the ones written by zap, zerolog, slog and logrus
+type jsonParser struct{}
+
+func (jsonParser) Name() string { return "json" }
+
+func (jsonParser) Detect(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed))
+}
+
+func (p jsonParser) Parse(text string) (Record, bool) {
+	trimmed := strings.TrimSpace(text)
+	if !p.Detect(trimmed) {
+		return Record{}, false
+	}
+
+	// Decode token by token, since unmarshalling into a map would lose the order of the fields
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return Record{}, false
+	}
+
+	var pairs []Field
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return Record{}, false
+		}
+		key, _ := tok.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return Record{}, false
+		}
+
+		pairs = append(pairs, Field{Key: key, Value: jsonValueString(raw)})
+	}
+
+	return newStructuredRecord(pairs), true
+}
+
+// jsonValueString turns a raw JSON value into something readable. Strings lose their quotes,
+// while numbers, booleans and nested objects are shown as written, minus any extra whitespace
+func jsonValueString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return string(raw)
+	}
+
+	return compact.String()
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// viewRecord is where a shown record starts in the viewport's lines, so it can be jumped to
+type viewRecord struct {
+	index     int
+	timestamp time.Time
+	line      int
+}
+
+// jumpTo scrolls to the record with a line number, like the ones shown before each record, or to the one
+// logged closest to a time. If that record is filtered out, the nearest one that's shown is used instead
+func (p *pane) jumpTo(target string) error {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil
+	}
+	if len(p.viewRecords) == 0 {
+		return errors.New("nothing to jump to")
+	}
+
+	var found viewRecord
+	if n, err := strconv.Atoi(target); err == nil {
+		found = closestIndex(p.viewRecords, n-1)
+	} else if t, ok := parseTimestamp(target); ok {
+		var err error
+		if found, err = closestTime(p.viewRecords, t); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("%q isn't a line number or a time", target)
+	}
+
+	p.scrollToLine(found.line)
+	p.pauseFollowing()
+	return nil
+}
+
+// closestIndex finds the shown record nearest to the one at index
+func closestIndex(records []viewRecord, index int) viewRecord {
+	best := records[0]
+	for _, r := range records[1:] {
+		if abs(r.index-index) < abs(best.index-index) {
+			best = r
+		}
+	}
+
+	return best
+}
+
+// closestTime finds the shown record logged nearest to t
+func closestTime(records []viewRecord, t time.Time) (viewRecord, error) {
+	var best viewRecord
+	var bestDistance time.Duration = -1
+
+	for _, r := range records {
+		if r.timestamp.IsZero() {
+			continue
+		}
+
+		distance := timeDistance(r.timestamp, t)
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = r, distance
+		}
+	}
+
+	if bestDistance < 0 {
+		return best, errors.New("no shown records have timestamps")
+	}
+
+	return best, nil
+}
+
+// timeDistance gets how far apart two timestamps are. If either one is only a time of day, just the times of day are compared
+func timeDistance(a, b time.Time) time.Duration {
+	if !hasDate(a) || !hasDate(b) {
+		return abs(timeOfDay(a) - timeOfDay(b))
+	}
+
+	return abs(a.Sub(b))
+}
+
+// abs gets the absolute value of a number
+func abs[T int | time.Duration](n T) T {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -1,7 +1,11 @@
 package models
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
 )
 
 func (k Keymap) ShortHelp() []key.Binding {
@@ -9,9 +13,13 @@ func (k Keymap) ShortHelp() []key.Binding {
 		k.Quit,
 		k.LineUp, k.LineDn,
 		k.HalfPgUp, k.HalfPgDn,
-		k.GoToTop, k.GoToEnd,
+		k.GoToTop, k.GoToEnd, k.Follow,
 		k.FocusFilter, k.NoFocusClearFilter,
 		k.SaveFilter, k.FocusedClearFilter,
+		k.AddExclusion, k.RemoveExclusion,
+		k.Search, k.NextMatch, k.GoTo,
+		k.CycleLayout, k.NextPane, k.Restart,
+		k.ToggleCase, k.ToggleFields,
 	}
 }
 
@@ -32,22 +40,89 @@ type Keymap struct {
 	GoToTop key.Binding
 	GoToEnd key.Binding
 
+	Follow key.Binding
+
 	FocusFilter key.Binding
 	SaveFilter  key.Binding
 
 	NoFocusClearFilter key.Binding
 	FocusedClearFilter key.Binding
 
-	ToggleInfo  key.Binding
-	ToggleWarn  key.Binding
-	ToggleError key.Binding
-	ToggleDebug key.Binding
-	ToggleFatal key.Binding
-	ToggleOther key.Binding
+	AddExclusion    key.Binding
+	RemoveExclusion key.Binding
+
+	Search    key.Binding
+	NextMatch key.Binding
+	PrevMatch key.Binding
+
+	GoTo key.Binding
+
+	CycleLayout key.Binding
+	NextPane    key.Binding
+	PrevPane    key.Binding
+
+	Restart key.Binding
+
+	ToggleCase key.Binding
+
+	ToggleLevels  []key.Binding // Indexed by LogLevel
+	ToggleSources []key.Binding // Indexed by source, for as many as there are keys
+
+	RaiseMinLevel key.Binding
+	LowerMinLevel key.Binding
+
+	CycleTimeWindow key.Binding
+
+	MoreContext key.Binding
+	LessContext key.Binding
+
+	ToggleFields key.Binding
 
 	Quit key.Binding
 }
 
+// LevelToggle finds the level whose toggle was pressed, if any
+func (k Keymap) LevelToggle(msg tea.KeyPressMsg) (LogLevel, bool) {
+	for i, binding := range k.ToggleLevels {
+		if key.Matches(msg, binding) {
+			return LogLevel(i), true
+		}
+	}
+
+	return OtherLevel, false
+}
+
+// bound reports whether a key is already used by any binding, level toggles included
+func (k Keymap) bound(name string) bool {
+	bindings := []key.Binding{
+		k.LineUp, k.LineDn, k.PageUp, k.PageDn, k.HalfPgUp, k.HalfPgDn, k.GoToTop, k.GoToEnd, k.Follow,
+		k.FocusFilter, k.SaveFilter, k.NoFocusClearFilter, k.FocusedClearFilter, k.AddExclusion, k.RemoveExclusion,
+		k.Search, k.NextMatch, k.PrevMatch, k.GoTo, k.CycleLayout, k.NextPane, k.PrevPane, k.Restart, k.ToggleCase,
+		k.RaiseMinLevel, k.LowerMinLevel, k.CycleTimeWindow, k.MoreContext, k.LessContext, k.ToggleFields, k.Quit,
+	}
+	bindings = append(bindings, k.ToggleLevels...)
+	bindings = append(bindings, k.ToggleSources...)
+
+	for _, binding := range bindings {
+		if slices.Contains(binding.Keys(), name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SourceToggle finds the source whose toggle was pressed, if any, out of the first count sources
+func (k Keymap) SourceToggle(msg tea.KeyPressMsg, count int) (int, bool) {
+	for i, binding := range k.ToggleSources[:min(count, len(k.ToggleSources))] {
+		if key.Matches(msg, binding) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
 func GetKeymap() Keymap {
 	m := Keymap{}
 
@@ -92,6 +167,11 @@ func GetKeymap() Keymap {
 		key.WithHelp("G", "end"),
 	)
 
+	m.Follow = key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "follow"),
+	)
+
 	// Filtering
 	m.FocusFilter = key.NewBinding(
 		key.WithKeys("f"),
@@ -115,12 +195,84 @@ func GetKeymap() Keymap {
 	)
 	m.SaveFilter.SetEnabled(false)
 
-	m.ToggleInfo = key.NewBinding(key.WithKeys("1"))
-	m.ToggleWarn = key.NewBinding(key.WithKeys("2"))
-	m.ToggleError = key.NewBinding(key.WithKeys("3"))
-	m.ToggleDebug = key.NewBinding(key.WithKeys("4"))
-	m.ToggleFatal = key.NewBinding(key.WithKeys("5"))
-	m.ToggleOther = key.NewBinding(key.WithKeys("6"))
+	m.AddExclusion = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "exclude"),
+	)
+
+	m.RemoveExclusion = key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "unexclude"),
+	)
+
+	// Searching
+	m.Search = key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	)
+
+	m.NextMatch = key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n/N", "next/prev match"),
+	)
+
+	m.PrevMatch = key.NewBinding(
+		key.WithKeys("N"),
+	)
+
+	m.GoTo = key.NewBinding(
+		key.WithKeys(":"),
+		key.WithHelp(":", "go to line/time"),
+	)
+
+	m.CycleLayout = key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "layout"),
+	)
+
+	m.NextPane = key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next pane"),
+	)
+
+	m.PrevPane = key.NewBinding(
+		key.WithKeys("shift+tab"),
+	)
+
+	m.Restart = key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "restart command"),
+	)
+
+	m.ToggleCase = key.NewBinding(
+		key.WithKeys("alt+c"),
+		key.WithHelp("alt+c", "ignore case"),
+	)
+
+	// Level toggles come from the level table, so custom levels get them too
+	for _, level := range levels {
+		binding := key.NewBinding(key.WithKeys(level.Key))
+		binding.SetEnabled(level.Key != "")
+		m.ToggleLevels = append(m.ToggleLevels, binding)
+	}
+
+	// Sources get alt and a number, so they don't clash with the levels
+	for i := 1; i <= 9; i++ {
+		m.ToggleSources = append(m.ToggleSources, key.NewBinding(key.WithKeys(fmt.Sprintf("alt+%d", i))))
+	}
+
+	m.RaiseMinLevel = key.NewBinding(key.WithKeys("+", "="))
+	m.LowerMinLevel = key.NewBinding(key.WithKeys("-", "_"))
+
+	m.CycleTimeWindow = key.NewBinding(key.WithKeys("t"))
+
+	m.MoreContext = key.NewBinding(key.WithKeys(">", "."))
+	m.LessContext = key.NewBinding(key.WithKeys("<", ","))
+
+	m.ToggleFields = key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "fields"),
+	)
 
 	// Control
 	m.Quit = key.NewBinding(
@@ -0,0 +1,508 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// DefaultLazyThreshold is how big a file has to be before it's indexed and read a window at a time, instead of all at once
+const DefaultLazyThreshold = 256 * 1024 * 1024
+
+const (
+	indexChunkSize = 16 * 1024 * 1024 // How much of the file gets indexed in one go, between progress updates
+	indexEntrySize = 16               // Bytes per index entry: the record's offset, then its line number
+
+	windowRecords = 500 // How many records are read around the view at a time
+)
+
+// hugeFile tracks a file too big to read all at once. An index of where each record starts is written to a
+// temporary file as the file gets scanned, and only a window of records around the view is actually read
+type hugeFile struct {
+	index *os.File // Index entries, one per record. It's already been removed, so it goes away once closed
+
+	entries int64   // Records indexed so far
+	lines   int64   // Lines indexed so far
+	indexed int64   // Bytes of the file indexed so far. Anything after the last complete line waits for the next pass
+	scanned int64   // Bytes of the file looked at so far, including a last line that hasn't been finished yet
+	size    int64   // How big the file was at the last check, to show indexing progress against
+	open    *record // The first line of the last record indexed, in case the next lines continue it
+	midLine bool    // Whether indexing stopped part way through a line too long to index in one go
+
+	// Whether there's an entry after the others for an unfinished last line that starts a record of its own.
+	// It's only there to show the line for now, and gets written over once the line's finished
+	pending bool
+
+	first   int64 // First record in the window
+	window  int   // Records in the window
+	readTo  int64 // Where in the file the window's last record ended, when it was read
+	loading bool  // Whether a window is being read
+}
+
+// close removes the index
+func (h *hugeFile) close() {
+	h.index.Close()
+}
+
+// indexing reports whether there's still some of the file left to index. An unfinished last line
+// doesn't count, since it can't be indexed until the rest of it is written
+func (h *hugeFile) indexing() bool {
+	return h.scanned < h.size
+}
+
+// records gets how many records there are to show, including one for an unfinished last line
+func (h *hugeFile) records() int64 {
+	if h.pending {
+		return h.entries + 1
+	}
+
+	return h.entries
+}
+
+// atEnd reports whether the window reaches the last record indexed so far
+func (h *hugeFile) atEnd() bool {
+	return h.first+int64(h.window) >= h.records()
+}
+
+// newIndexFile creates the file for a huge file's index. It's removed straight away, so it can't be left behind
+func newIndexFile() (*os.File, error) {
+	file, err := os.CreateTemp("", "campfire-index-*")
+	if err != nil {
+		return nil, err
+	}
+
+	// Some platforms won't remove files that are open, so then it's left for the temp directory's cleanup
+	os.Remove(file.Name())
+
+	return file, nil
+}
+
+// indexMore indexes the next chunk of a huge file
+func (s *source) indexMore() tea.Cmd {
+	h := *s.huge
+	return indexFile(s.id, s.filename, s.prevFileInfo, h, s.options, s.parser)
+}
+
+// indexFile scans the next chunk of a huge file for where records start, and adds them to its index.
+// If the file was rotated, it's checked from scratch instead, since the new one might not even be huge
+func indexFile(src int, name string, prev os.FileInfo, h hugeFile, opts Options, parser Parser) tea.Cmd {
+	return func() tea.Msg {
+		info, err := os.Stat(name)
+		if os.IsNotExist(err) {
+			return fileGoneMsg{src}
+		}
+		if err != nil {
+			return fileErrorMsg{src, err}
+		}
+
+		if detectRotation(prev, info, h.indexed) != notRotated {
+			return checkFile(src, name, prev, h.indexed, opts.LazyThreshold)()
+		}
+
+		msg := indexedMsg{
+			source:  src,
+			index:   h.index,
+			info:    info,
+			entries: h.entries,
+			lines:   h.lines,
+			indexed: h.indexed,
+			scanned: h.indexed,
+			open:    h.open,
+			midLine: h.midLine,
+			parser:  parser,
+		}
+		if info.Size() == h.indexed {
+			return msg
+		}
+
+		file, err := os.Open(name)
+		if err != nil {
+			return fileErrorMsg{src, err}
+		}
+		defer file.Close()
+
+		chunk := make([]byte, min(info.Size()-h.indexed, indexChunkSize))
+		n, err := file.ReadAt(chunk, h.indexed)
+		if err != nil && err != io.EOF {
+			return fileErrorMsg{src, err}
+		}
+		chunk = chunk[:n]
+		atEOF := h.indexed+int64(n) == info.Size()
+
+		// Only complete lines get indexed, and the rest waits for the next chunk. A line that doesn't fit
+		// in a chunk at all gets indexed a piece at a time instead, or it'd never be finished
+		end := bytes.LastIndexByte(chunk, '\n') + 1
+		if end == 0 && !atEOF {
+			end = n
+		}
+		text := string(chunk[:end])
+		finished := strings.HasSuffix(text, "\n")
+
+		var lines []string
+		if text != "" {
+			lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+		}
+
+		// Work out the file's format from the first lines, like with files that are read whole
+		if msg.parser == nil && h.indexed == 0 && len(lines) > 0 {
+			msg.parser = detectParser(lines[:min(len(lines), 100)])
+		}
+
+		scratch := &source{options: opts}
+		entries := make([]byte, 0, (len(lines)+1)*indexEntrySize)
+		offset := h.indexed
+		for i, line := range lines {
+			// The rest of a line that was too long for the last chunk is already part of its record
+			if !msg.midLine && !scratch.continuesRecord(msg.open, line) {
+				entries = binary.LittleEndian.AppendUint64(entries, uint64(offset))
+				entries = binary.LittleEndian.AppendUint64(entries, uint64(msg.lines))
+				msg.open = &record{lines: []string{line}}
+				msg.entries++
+			}
+
+			offset += int64(len(line))
+			msg.midLine = i == len(lines)-1 && !finished
+			if !msg.midLine {
+				offset++
+				msg.lines++
+			}
+		}
+		msg.indexed, msg.scanned = offset, offset
+
+		// An unfinished last line is shown for now, and indexed for real once it's finished
+		if rest := string(chunk[end:]); atEOF && rest != "" {
+			msg.scanned = info.Size()
+
+			if !msg.midLine && !scratch.continuesRecord(msg.open, rest) {
+				entries = binary.LittleEndian.AppendUint64(entries, uint64(offset))
+				entries = binary.LittleEndian.AppendUint64(entries, uint64(msg.lines))
+				msg.pending = true
+			}
+		}
+
+		if _, err := h.index.WriteAt(entries, h.entries*indexEntrySize); err != nil {
+			return fileErrorMsg{src, err}
+		}
+
+		return msg
+	}
+}
+
+// windowAt reads the window of records starting at first
+func (s *source) windowAt(first int64, anchor windowAnchor) tea.Cmd {
+	s.huge.loading = true
+	return loadWindow(s.id, s.filename, *s.huge, first, -1, anchor, s.currentParser())
+}
+
+// windowAround reads a window of records centered on the one a line is part of, and jumps to it
+func (s *source) windowAround(line int64) tea.Cmd {
+	s.huge.loading = true
+	return loadWindow(s.id, s.filename, *s.huge, 0, line, windowAnchor{line: int(line), jump: true}, s.currentParser())
+}
+
+// windowAnchor is a record to keep in view once a window is loaded
+type windowAnchor struct {
+	line int  // The record's line number. Below 0 for none
+	jump bool // Whether to jump to it like with :, rather than keeping it at the top
+}
+
+// noAnchor leaves the view wherever it ends up
+var noAnchor = windowAnchor{line: -1}
+
+// loadWindow reads and parses a window of records from a huge file, starting at the first one, or centered on
+// the record containing a line if that's 0 or more
+func loadWindow(src int, name string, h hugeFile, first int64, line int64, anchor windowAnchor, parser Parser) tea.Cmd {
+	return func() tea.Msg {
+		total := h.records()
+		if line >= 0 {
+			found := int64(sort.Search(int(total), func(i int) bool {
+				entry, err := readIndexEntry(h.index, int64(i))
+				return err != nil || entry.line > line
+			})) - 1
+
+			first = found - windowRecords/2
+		}
+		first = max(0, min(first, total-windowRecords))
+
+		count := min(windowRecords, total-first)
+		raw := make([]byte, count*indexEntrySize)
+		if _, err := h.index.ReadAt(raw, first*indexEntrySize); err != nil {
+			return windowLoadedMsg{source: src, index: h.index, err: err}
+		}
+
+		entries := make([]indexEntry, count)
+		for i := range entries {
+			entries[i] = decodeIndexEntry(raw[i*indexEntrySize:])
+		}
+
+		// The window runs until the next record, or the end of what's been looked at
+		end := h.scanned
+		if first+count < total {
+			next, err := readIndexEntry(h.index, first+count)
+			if err != nil {
+				return windowLoadedMsg{source: src, index: h.index, err: err}
+			}
+			end = next.offset
+		}
+
+		file, err := os.Open(name)
+		if err != nil {
+			return windowLoadedMsg{source: src, index: h.index, err: err}
+		}
+		defer file.Close()
+
+		start := int64(0)
+		if count > 0 {
+			start = entries[0].offset
+		}
+		content := make([]byte, end-start)
+		if _, err := file.ReadAt(content, start); err != nil && err != io.EOF {
+			return windowLoadedMsg{source: src, index: h.index, err: err}
+		}
+
+		records := make([]LogMessage, count)
+		for i, entry := range entries {
+			stop := end
+			if i+1 < len(entries) {
+				stop = entries[i+1].offset
+			}
+
+			text := strings.TrimSuffix(string(content[entry.offset-start:stop-start]), "\n")
+			records[i] = NewLogMessage(int(entry.line), text, parser)
+			records[i].source = src
+		}
+
+		return windowLoadedMsg{source: src, index: h.index, first: first, records: records, end: end, anchor: anchor}
+	}
+}
+
+// indexEntry is where a record starts in a huge file
+type indexEntry struct {
+	offset int64
+	line   int64
+}
+
+func decodeIndexEntry(b []byte) indexEntry {
+	return indexEntry{
+		offset: int64(binary.LittleEndian.Uint64(b)),
+		line:   int64(binary.LittleEndian.Uint64(b[8:])),
+	}
+}
+
+// readIndexEntry reads one entry from an index
+func readIndexEntry(index *os.File, i int64) (indexEntry, error) {
+	b := make([]byte, indexEntrySize)
+	if _, err := index.ReadAt(b, i*indexEntrySize); err != nil {
+		return indexEntry{}, err
+	}
+
+	return decodeIndexEntry(b), nil
+}
+
+// syncWindow loads a huge source's first window as soon as there's something indexed, and keeps the
+// window at the end once indexing is done, if it's being followed. That includes an unfinished last line
+// that's grown since the window was read
+func (m *model) syncWindow(src *source) tea.Cmd {
+	h := src.huge
+	if h == nil || h.loading || h.records() == 0 {
+		return nil
+	}
+
+	switch {
+	case !h.indexing() && m.followed(src) && (h.window == 0 || !h.atEnd() || h.readTo < h.scanned):
+		return src.windowAt(h.records()-windowRecords, noAnchor)
+	case h.window == 0:
+		return src.windowAt(0, noAnchor)
+	}
+
+	return nil
+}
+
+// followed reports whether any pane on screen showing a source is following it
+func (m model) followed(src *source) bool {
+	for _, p := range m.visiblePanes() {
+		if p.following && p.shows(src) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scroll is which way a key or the mouse wheel just moved the view, for working out where to move huge files' windows
+type scroll int
+
+const (
+	scrollNone scroll = iota
+	scrollUp
+	scrollDown
+	scrollTop
+)
+
+// moveWindows loads further along any huge file in the focused pane once the view gets within a screen of the
+// edge of its window, keeping the record at the top of the view where it is. The window can also be sent to the top
+func (m *model) moveWindows(s scroll) []tea.Cmd {
+	p := m.pane
+	top, shown := p.topRecord()
+
+	// With nothing on screen the view counts as being at the bottom, so scrolling up didn't stop following yet
+	if !shown && s == scrollUp {
+		p.following = false
+		p.unseen = 0
+	}
+
+	// Nothing in the windows got through the filters, so there's nothing to scroll through. Step through
+	// the files a window at a time in the direction of the scroll instead, until something does
+	if !shown && !p.following && (s == scrollUp || s == scrollDown) {
+		return p.stepWindows(s)
+	}
+
+	if s == scrollTop || p.following {
+		p.seek = scrollNone
+	}
+
+	var cmds []tea.Cmd
+	for _, src := range p.files {
+		h := src.huge
+		if h == nil || h.loading || h.window == 0 {
+			continue
+		}
+
+		if s == scrollTop {
+			if h.first > 0 {
+				cmds = append(cmds, src.windowAt(0, windowAnchor{line: 0}))
+			}
+			continue
+		}
+
+		if p.following {
+			cmds = append(cmds, m.syncWindow(src))
+			continue
+		}
+
+		if !shown {
+			continue
+		}
+
+		height := p.viewport.Height() - p.viewport.Style.GetVerticalFrameSize()
+		bottom := p.lineOffset(len(p.viewLines))
+		anchor := windowAnchor{line: top.index}
+
+		switch {
+		case p.viewport.YOffset < height && h.first > 0:
+			cmds = append(cmds, src.windowAt(h.first-windowRecords/2, anchor))
+		case p.viewport.YOffset+2*height > bottom && !h.atEnd():
+			cmds = append(cmds, src.windowAt(h.first+windowRecords/2, anchor))
+		}
+	}
+
+	return cmds
+}
+
+// stepWindows moves the windows of a pane's huge files on by a whole window in the direction of the scroll,
+// past records that all got filtered out. It stops seeking once every file has reached the end it's heading for
+func (p *pane) stepWindows(s scroll) []tea.Cmd {
+	var cmds []tea.Cmd
+	for _, src := range p.files {
+		h := src.huge
+		if h == nil || h.loading || h.window == 0 {
+			continue
+		}
+
+		switch {
+		case s == scrollUp && h.first > 0:
+			cmds = append(cmds, src.windowAt(h.first-windowRecords, noAnchor))
+		case s == scrollDown && !h.atEnd():
+			cmds = append(cmds, src.windowAt(h.first+int64(h.window), noAnchor))
+		}
+	}
+
+	p.seek = scrollNone
+	if len(cmds) > 0 {
+		p.seek = s
+	}
+
+	return cmds
+}
+
+// keepSeeking carries on stepping through a pane's huge files once the render with their new windows comes in,
+// until something gets through the filters. Seeking up lands on the last of what turned up, like scrolling onto it
+func (p *pane) keepSeeking(render int) []tea.Cmd {
+	if p.seek == scrollNone || render <= p.seekAfter || slices.ContainsFunc(p.files, (*source).loadingWindow) {
+		return nil
+	}
+
+	if len(p.viewRecords) == 0 {
+		return p.stepWindows(p.seek)
+	}
+
+	if p.seek == scrollUp {
+		p.viewport.GotoBottom()
+	}
+	p.seek = scrollNone
+
+	return nil
+}
+
+// loadingWindow reports whether a huge source has a window being read
+func (s *source) loadingWindow() bool {
+	return s.huge != nil && s.huge.loading
+}
+
+// jumpToLine jumps to a line in a huge file in the focused pane, reading the window around it.
+// It only applies if the pane just shows the one file and the target is a line number
+func (m *model) jumpToLine(target string) (tea.Cmd, bool) {
+	if len(m.pane.files) != 1 || m.pane.files[0].huge == nil {
+		return nil, false
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(target))
+	if err != nil {
+		return nil, false
+	}
+
+	// Stop following now, or the window would just get sent back to the end
+	m.pane.following = false
+	m.pane.unseen = 0
+	m.pane.seek = scrollNone
+
+	return m.pane.files[0].windowAround(int64(n - 1)), true
+}
+
+// topRecord finds the record at the top of the view
+func (p *pane) topRecord() (viewRecord, bool) {
+	var top viewRecord
+	found := false
+	for _, r := range p.viewRecords {
+		if p.lineOffset(r.line) > p.viewport.YOffset {
+			break
+		}
+		top, found = r, true
+	}
+
+	return top, found
+}
+
+// applyAnchor puts the record a window was loaded around back in view, once the render with the new window comes in
+func (p *pane) applyAnchor(render int) {
+	if p.anchor.line < 0 || render <= p.anchorAfter || len(p.viewRecords) == 0 {
+		return
+	}
+
+	found := closestIndex(p.viewRecords, p.anchor.line)
+	if p.anchor.jump {
+		p.scrollToLine(found.line)
+		p.pauseFollowing()
+	} else if !p.following {
+		p.viewport.SetYOffset(p.lineOffset(found.line))
+	}
+
+	p.anchor = noAnchor
+}
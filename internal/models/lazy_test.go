@@ -0,0 +1,260 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+func TestIndexFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantEntries int64 // Records indexed for good
+		wantLines   int64
+		wantPending bool // Whether there's an entry for an unfinished last line
+	}{
+		{"empty", "", 0, 0, false},
+		{"complete lines", "one\ntwo\nthree\n", 3, 3, false},
+		{"unfinished last line", "one\ntwo\nthr", 2, 2, true},
+		{"only an unfinished line", "one", 0, 0, true},
+		{"blank lines", "one\n\n\ntwo\n", 4, 4, false},
+		{
+			"continuation lines",
+			"2024-05-01T10:00:00Z ERROR failed\n  at main.go:10\n  at main.go:20\n2024-05-01T10:00:01Z INFO fine\n",
+			2, 4, false,
+		},
+		{
+			"unfinished continuation line",
+			"2024-05-01T10:00:00Z ERROR failed\n  at main.go:10\n  at main",
+			1, 2, false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "huge.log")
+			writeFile(t, name, tt.content)
+
+			h := indexAll(t, name, newHugeFile(t))
+			if h.entries != tt.wantEntries || h.lines != tt.wantLines || h.pending != tt.wantPending {
+				t.Errorf("indexed %d records and %d lines (pending %t), want %d and %d (pending %t)",
+					h.entries, h.lines, h.pending, tt.wantEntries, tt.wantLines, tt.wantPending)
+			}
+			if h.scanned != int64(len(tt.content)) {
+				t.Errorf("scanned %d bytes, want all %d", h.scanned, len(tt.content))
+			}
+		})
+	}
+}
+
+func TestIndexFileFinishesLine(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "huge.log")
+	writeFile(t, name, "one\ntwo\nthr")
+
+	h := indexAll(t, name, newHugeFile(t))
+	records := readWindow(t, name, h)
+	if got := messages(records); got != "one|two|thr" {
+		t.Errorf("window before the line's finished = %q", got)
+	}
+
+	appendFile(t, name, "ee\nfour\n")
+	h = indexAll(t, name, h)
+	if h.entries != 4 || h.lines != 4 || h.pending {
+		t.Errorf("indexed %d records and %d lines (pending %t), want 4 and 4", h.entries, h.lines, h.pending)
+	}
+
+	records = readWindow(t, name, h)
+	if got := messages(records); got != "one|two|three|four" {
+		t.Errorf("window after the line's finished = %q", got)
+	}
+}
+
+func TestIndexFileLongLine(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "huge.log")
+	long := strings.Repeat("x", indexChunkSize*2+indexChunkSize/2)
+	writeFile(t, name, "first\n"+long+"\nlast\n")
+
+	h := indexAll(t, name, newHugeFile(t))
+	if h.entries != 3 || h.lines != 3 || h.midLine {
+		t.Fatalf("indexed %d records and %d lines (mid line %t), want 3 and 3", h.entries, h.lines, h.midLine)
+	}
+
+	records := readWindow(t, name, h)
+	if len(records) != 3 || records[1].message != long || records[2].message != "last" || records[2].index != 2 {
+		t.Errorf("long line wasn't read back as one record")
+	}
+}
+
+func TestStaleIndexedMsg(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	writeFile(t, name, "one\n")
+
+	m := NewModel([]string{name}, Options{})
+
+	// The file isn't huge, so there's no index for this to belong to
+	m.Update(indexedMsg{source: 0, index: newHugeFile(t).index, entries: 5, lines: 5})
+	if m.sources[0].huge != nil {
+		t.Error("a stale index made the file huge")
+	}
+
+	// Nor is it for the index the file has now
+	m.sources[0].huge = newHugeFile(t)
+	m.Update(indexedMsg{source: 0, index: newHugeFile(t).index, entries: 5, lines: 5})
+	if m.sources[0].huge.entries != 0 {
+		t.Error("a message for an old index was applied to the new one")
+	}
+}
+
+func TestScrollPastFilteredWindows(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "huge.log")
+	var content strings.Builder
+	for i := range 6001 {
+		if i == 3000 {
+			content.WriteString("ERROR boom\n")
+		} else {
+			fmt.Fprintf(&content, "INFO line %d\n", i)
+		}
+	}
+	writeFile(t, name, content.String())
+
+	tests := []struct {
+		name   string
+		first  int64 // Start of the window the view starts on
+		key    rune
+		follow bool
+	}{
+		{"up from the last window", 6001 - windowRecords, tea.KeyUp, false},
+		{"up while following", 6001 - windowRecords, tea.KeyUp, true},
+		{"down from the first window", 0, tea.KeyDown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel([]string{name}, Options{})
+			m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+			m.filters.SetFilterText("boom")
+			m.following = tt.follow
+
+			src := m.sources[0]
+			src.huge = indexAll(t, name, newHugeFile(t))
+			settle(t, m, src.windowAt(tt.first, noAnchor))
+			if len(m.viewRecords) != 0 {
+				t.Fatalf("the filter let %d records through in the first window", len(m.viewRecords))
+			}
+
+			_, cmd := m.Update(tea.KeyPressMsg{Code: tt.key})
+			settle(t, m, cmd)
+
+			if len(m.viewRecords) != 1 || m.viewRecords[0].index != 3000 {
+				t.Errorf("after scrolling, showing %v from the window at %d, want record 3000", m.viewRecords, src.huge.first)
+			}
+			if m.seek != scrollNone {
+				t.Error("still seeking after finding a match")
+			}
+		})
+	}
+}
+
+// settle runs a command and the ones that follow from it through the model, until there are none left.
+// Commands that don't finish straight away, like ticks, are dropped
+func settle(t *testing.T, m *model, cmd tea.Cmd) {
+	t.Helper()
+	if cmd == nil {
+		return
+	}
+
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(100 * time.Millisecond):
+		return
+	}
+
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, cmd := range batch {
+			settle(t, m, cmd)
+		}
+		return
+	}
+
+	_, next := m.Update(msg)
+	settle(t, m, next)
+}
+
+// newHugeFile makes an empty index, to index a file from the top
+func newHugeFile(t *testing.T) *hugeFile {
+	t.Helper()
+	index, err := newIndexFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { index.Close() })
+
+	return &hugeFile{index: index}
+}
+
+// indexAll indexes a file like the indexedMsg handler does, failing if it doesn't finish
+func indexAll(t *testing.T, name string, h *hugeFile) *hugeFile {
+	t.Helper()
+	info := stat(t, name)
+	h.size = info.Size()
+
+	for pass := 0; pass == 0 || h.indexing(); pass++ {
+		if pass == 100 {
+			t.Fatalf("still indexing after %d passes, at %d of %d bytes", pass, h.scanned, h.size)
+		}
+
+		msg, ok := indexFile(0, name, info, *h, Options{}, plainParser{})().(indexedMsg)
+		if !ok {
+			t.Fatal("indexing didn't return an indexedMsg")
+		}
+
+		h.entries, h.lines, h.indexed, h.scanned, h.open = msg.entries, msg.lines, msg.indexed, msg.scanned, msg.open
+		h.midLine, h.pending, h.size = msg.midLine, msg.pending, msg.info.Size()
+	}
+
+	return h
+}
+
+// readWindow reads the first window of records from an indexed file
+func readWindow(t *testing.T, name string, h *hugeFile) []LogMessage {
+	t.Helper()
+	msg := loadWindow(0, name, *h, 0, -1, noAnchor, plainParser{})().(windowLoadedMsg)
+	if msg.err != nil {
+		t.Fatal(msg.err)
+	}
+
+	return msg.records
+}
+
+// messages joins up the text of each record
+func messages(records []LogMessage) string {
+	texts := make([]string, len(records))
+	for i, r := range records {
+		texts[i] = r.message
+	}
+
+	return strings.Join(texts, "|")
+}
+
+func appendFile(t *testing.T, name, content string) {
+	t.Helper()
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
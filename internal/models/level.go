@@ -0,0 +1,275 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// maxLevels is how many levels the level table can hold, since Filters keeps one bit per level
+const maxLevels = 64
+
+// LogLevel represents typical log output levels. It's an index into the level table
+type LogLevel int
+
+const (
+	TraceLevel LogLevel = iota
+	DebugLevel
+	InfoLevel
+	NoticeLevel
+	WarnLevel
+	ErrorLevel
+	CriticalLevel
+	FatalLevel
+	PanicLevel
+	OtherLevel
+)
+
+// Level describes everything campfire needs to know about one log level
+type Level struct {
+	Name     string         // How the level is shown, like "WARN"
+	Aliases  []string       // Other spellings of the level, which are matched ignoring case
+	Severity int            // How serious the level is, used to order the levels. Higher is worse
+	Style    lipgloss.Style // How messages of the level are drawn
+	Key      string         // Key that shows or hides messages of the level, if any
+}
+
+// levels is the level table, indexed by LogLevel. Custom levels get added to the end
+var levels = []Level{
+	TraceLevel:    {Name: "TRACE", Aliases: []string{"trc"}, Severity: 10, Style: traceStyle, Key: "7"},
+	DebugLevel:    {Name: "DEBUG", Aliases: []string{"debu", "dbg", "d"}, Severity: 20, Style: debugStyle, Key: "4"},
+	InfoLevel:     {Name: "INFO", Aliases: []string{"inf", "information", "i"}, Severity: 30, Style: infoStyle, Key: "1"},
+	NoticeLevel:   {Name: "NOTICE", Severity: 35, Style: noticeStyle, Key: "8"},
+	WarnLevel:     {Name: "WARN", Aliases: []string{"warning", "wrn", "w"}, Severity: 40, Style: warnStyle, Key: "2"},
+	ErrorLevel:    {Name: "ERROR", Aliases: []string{"erro", "err", "e"}, Severity: 50, Style: errorStyle, Key: "3"},
+	CriticalLevel: {Name: "CRIT", Aliases: []string{"critical", "crt"}, Severity: 55, Style: criticalStyle, Key: "9"},
+	FatalLevel:    {Name: "FATAL", Aliases: []string{"fata", "alert", "emerg", "f"}, Severity: 60, Style: fatalStyle, Key: "5"},
+	PanicLevel:    {Name: "PANIC", Aliases: []string{"dpanic"}, Severity: 70, Style: panicStyle, Key: "0"},
+	OtherLevel:    {Name: "OTHER", Severity: 0, Style: lipgloss.NewStyle(), Key: "6"},
+}
+
+// levelsByAlias finds a level from any of its spellings, lowercased
+var levelsByAlias = indexLevels()
+
+// RegisterLevel adds a custom level to the level table. Its name and aliases are
+// recognised in logs from then on, and it gets its own toggle if it has a key
+func RegisterLevel(l Level) (LogLevel, error) {
+	if len(levels) == maxLevels {
+		return OtherLevel, fmt.Errorf("can't have more than %d levels", maxLevels)
+	}
+
+	if l.Key != "" && GetKeymap().bound(l.Key) {
+		return OtherLevel, fmt.Errorf("key %q is already taken", l.Key)
+	}
+
+	for _, name := range append([]string{l.Name}, l.Aliases...) {
+		if levelTaken(name) {
+			return OtherLevel, fmt.Errorf("there's already a level called %s", name)
+		}
+	}
+
+	levels = append(levels, l)
+	levelsByAlias = indexLevels()
+
+	return LogLevel(len(levels) - 1), nil
+}
+
+// LookupLevel finds a level by its name or any of its aliases, ignoring case
+func LookupLevel(name string) (LogLevel, bool) {
+	level, ok := levelsByAlias[strings.ToLower(name)]
+	return level, ok
+}
+
+// levelTaken reports whether a name already means a level, including OTHER
+func levelTaken(name string) bool {
+	_, ok := LookupLevel(name)
+	return ok || strings.EqualFold(name, OtherLevel.String())
+}
+
+// ParseLevelSpec reads a custom level from its command line form, `NAME[:COLOR[:SEVERITY[:KEY]]]`.
+// Without a severity, the level ranks alongside INFO
+func ParseLevelSpec(spec string) (Level, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) > 4 || strings.TrimSpace(parts[0]) == "" {
+		return Level{}, fmt.Errorf("expected NAME[:COLOR[:SEVERITY[:KEY]]], got %q", spec)
+	}
+
+	l := Level{
+		Name:     strings.ToUpper(strings.TrimSpace(parts[0])),
+		Severity: InfoLevel.Severity(),
+		Style:    lipgloss.NewStyle(),
+	}
+
+	if levelTaken(l.Name) {
+		return Level{}, fmt.Errorf("there's already a level called %s", l.Name)
+	}
+
+	if len(parts) > 1 && parts[1] != "" {
+		l.Style = l.Style.Foreground(lipgloss.Color(parts[1]))
+	}
+
+	if len(parts) > 2 && parts[2] != "" {
+		severity, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return Level{}, fmt.Errorf("invalid severity %q for level %s", parts[2], l.Name)
+		}
+		l.Severity = severity
+	}
+
+	if len(parts) > 3 {
+		l.Key = parts[3]
+		if l.Key != "" && GetKeymap().bound(l.Key) {
+			return Level{}, fmt.Errorf("key %q for level %s is already taken", l.Key, l.Name)
+		}
+	}
+
+	return l, nil
+}
+
+// indexLevels maps every name and alias in the level table to its level
+func indexLevels() map[string]LogLevel {
+	index := make(map[string]LogLevel)
+
+	for i, l := range levels {
+		if LogLevel(i) == OtherLevel {
+			continue
+		}
+
+		index[strings.ToLower(l.Name)] = LogLevel(i)
+		for _, alias := range l.Aliases {
+			index[strings.ToLower(alias)] = LogLevel(i)
+		}
+	}
+
+	return index
+}
+
+func (l LogLevel) String() string {
+	if int(l) < 0 || int(l) >= len(levels) {
+		return ""
+	}
+
+	return levels[l].Name
+}
+
+// Style gets the style that messages of the level are drawn with
+func (l LogLevel) Style() lipgloss.Style {
+	return levels[l].Style
+}
+
+// Severity gets how serious the level is. Higher is worse
+func (l LogLevel) Severity() int {
+	return levels[l].Severity
+}
+
+// levelsBySeverity lists every level from least to most severe, with OTHER at the end
+func levelsBySeverity() []LogLevel {
+	ordered := make([]LogLevel, 0, len(levels))
+	for i := range levels {
+		if LogLevel(i) != OtherLevel {
+			ordered = append(ordered, LogLevel(i))
+		}
+	}
+
+	slices.SortStableFunc(ordered, func(a, b LogLevel) int {
+		return cmp.Compare(a.Severity(), b.Severity())
+	})
+
+	return append(ordered, OtherLevel)
+}
+
+// levelForSeverity finds the most severe level that isn't more severe than the given rank
+func levelForSeverity(severity int) LogLevel {
+	found := OtherLevel
+	for _, l := range levelsBySeverity() {
+		if l != OtherLevel && l.Severity() <= severity {
+			found = l
+		}
+	}
+
+	// Anything below the least severe level still counts as that level
+	if found == OtherLevel {
+		return levelsBySeverity()[0]
+	}
+
+	return found
+}
+
+var (
+	// timestampPrefix matches the timestamps lines commonly start with: a date with an optional time,
+	// a syslog style month and day, or a time on its own, optionally in brackets
+	timestampPrefix = regexp.MustCompile(`^\[?(?:` +
+		`\d{4}[-/]\d{2}[-/]\d{2}(?:[T ]\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?` +
+		`|[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}` +
+		`|\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?(?: ?[AaPp][Mm])?` +
+		`)\]?`)
+
+	// glogPrefix matches glog and klog lines, which glue the level's letter onto the date, i.e. `I0102 15:04:05.000000`
+	glogPrefix = regexp.MustCompile(`^([IWEF])\d{4} `)
+)
+
+// prefixWords is how many words into a line, after any timestamp, guessLevel looks for a level
+const prefixWords = 6
+
+// guessLevel looks for a level in the prefix of an unstructured line, after any timestamp. It steps over
+// the thread and logger names formats put before the level, like the `[main]` in logback's
+// `10:00:00.123 [main] INFO com.x - ...` or the `- myapp -` in Python's `... - myapp - ERROR - ...`,
+// and stops at the first word of the message. Only whole words count, so `DEBUT` isn't mistaken for
+// `DEBU`, and a level mentioned in the message, like `ERRO failed to fetch INFO endpoint`, doesn't
+// override the real one. One letter levels are only trusted in glog's form, since a word like `I` or
+// `e` is usually just a word
+func guessLevel(line string) LogLevel {
+	if match := glogPrefix.FindStringSubmatch(line); match != nil {
+		return levelsByAlias[strings.ToLower(match[1])]
+	}
+
+	rest := timestampPrefix.ReplaceAllString(line, "")
+	fields := strings.Fields(rest)
+	bracketed := false
+
+	for i, field := range fields[:min(len(fields), prefixWords)] {
+		// Levels are often wrapped up in punctuation, like `[warn]` or `ERROR:`
+		word := strings.Trim(field, "[](){}<>:|-=,;*#")
+		if level, ok := levelsByAlias[strings.ToLower(word)]; ok && len(word) > 1 {
+			return level
+		}
+
+		switch {
+		case bracketed || strings.ContainsAny(field[:1], "[(<{"):
+			// A thread or logger name in brackets, which can have spaces in it
+			bracketed = !strings.ContainsAny(field[len(field)-1:], "])>}")
+		case isSeparator(field):
+			// A separator, like `-` or `|`
+		case i+1 < len(fields) && isSeparator(fields[i+1]):
+			// A name set off by a separator, like `myapp -`
+		default:
+			return OtherLevel
+		}
+	}
+
+	return OtherLevel
+}
+
+// isSeparator reports whether a word is only punctuation separating the parts of a line's prefix
+func isSeparator(word string) bool {
+	return strings.Trim(word, "-|:=") == ""
+}
+
+// levelFromName maps the level names used by common logging libraries onto campfire's levels.
+// Numeric levels, like the ones pino and bunyan write, are treated as severities
+func levelFromName(name string) LogLevel {
+	if n, err := strconv.Atoi(name); err == nil {
+		return levelForSeverity(n)
+	}
+
+	if level, ok := levelsByAlias[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return level
+	}
+
+	return OtherLevel
+}
@@ -0,0 +1,141 @@
+package models
+
+import "testing"
+
+func TestGuessLevel(t *testing.T) {
+	tests := []struct {
+		line string
+		want LogLevel
+	}{
+		// glog and klog
+		{"I0102 15:04:05.000000   123 main.go:10] started", InfoLevel},
+		{"W0102 15:04:05.000000   123 main.go:10] slow", WarnLevel},
+		{"E0102 15:04:05.000000   123 main.go:10] failed", ErrorLevel},
+		{"F0102 15:04:05.000000   123 main.go:10] gave up", FatalLevel},
+
+		// Names and aliases, wrapped up in punctuation or not
+		{"[warn] disk nearly full", WarnLevel},
+		{"WARNING: disk nearly full", WarnLevel},
+		{"ERR connection refused", ErrorLevel},
+		{"CRIT out of memory", CriticalLevel},
+		{"TRACE entering handler", TraceLevel},
+		{"PANIC: nil map", PanicLevel},
+		{"NOTICE config reloaded", NoticeLevel},
+		{"<debug> cache miss", DebugLevel},
+
+		// Case doesn't matter
+		{"info started", InfoLevel},
+		{"Error: connection refused", ErrorLevel},
+		{"wArN disk nearly full", WarnLevel},
+
+		// After a timestamp
+		{"2024-05-01T14:02:03Z INFO started", InfoLevel},
+		{"2024-05-01 14:02:03,123 ERROR failed", ErrorLevel},
+		{"[14:02:03] WARN slow", WarnLevel},
+		{"Oct 11 22:14:15 DEBUG cache miss", DebugLevel},
+
+		// After thread and logger names
+		{"10:00:00.123 [main] INFO com.x - started", InfoLevel},
+		{"10:00:00.123 [pool-1 thread-2] WARN com.x - slow", WarnLevel},
+		{"2024-05-01 14:02:03,123 - myapp - ERROR - failed", ErrorLevel},
+		{"web | DEBUG | cache miss", DebugLevel},
+		{"10:00:00.123 [main] started INFO endpoint", OtherLevel},
+
+		// Only whole words
+		{"DEBUT of the new feature", OtherLevel},
+		{"Informational only", OtherLevel},
+
+		// Not once the message has started
+		{"ERRO failed to fetch INFO endpoint", ErrorLevel},
+		{"failed to fetch INFO endpoint", OtherLevel},
+		{"2024-05-01T14:02:03Z request took 30s ERROR", OtherLevel},
+
+		// One letter words aren't levels outside of glog
+		{"I think so", OtherLevel},
+		{"e logged in", OtherLevel},
+		{"user e logged in", OtherLevel},
+		{"Connected to d server", OtherLevel},
+		{"Step w done", OtherLevel},
+		{"I01 started", OtherLevel},
+
+		{"", OtherLevel},
+		{"   ", OtherLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := guessLevel(tt.line); got != tt.want {
+				t.Errorf("guessLevel(%q) = %v, want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLevelSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		name     string
+		severity int
+		key      string
+		wantErr  bool
+	}{
+		{spec: "audit", name: "AUDIT", severity: 30},
+		{spec: " audit ", name: "AUDIT", severity: 30},
+		{spec: "AUDIT:#89dceb", name: "AUDIT", severity: 30},
+		{spec: "AUDIT:#89dceb:45", name: "AUDIT", severity: 45},
+		{spec: "AUDIT::45:a", name: "AUDIT", severity: 45, key: "a"},
+		{spec: "AUDIT:::", name: "AUDIT", severity: 30},
+		{spec: "AUDIT:::a", name: "AUDIT", severity: 30, key: "a"},
+		{spec: "", wantErr: true},
+		{spec: ":red", wantErr: true},
+		{spec: "AUDIT::loud", wantErr: true},
+		{spec: "AUDIT::45:a:b", wantErr: true},
+
+		// Keys that something else already uses
+		{spec: "AUDIT::45:e", wantErr: true},
+		{spec: "AUDIT::45:f", wantErr: true},
+		{spec: "AUDIT::45:3", wantErr: true},
+		{spec: "AUDIT::45:alt+1", wantErr: true},
+
+		// Names that are already levels
+		{spec: "warn", wantErr: true},
+		{spec: "Warning:red", wantErr: true},
+		{spec: "other", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			l, err := ParseLevelSpec(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseLevelSpec(%q) = %+v, want an error", tt.spec, l)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseLevelSpec(%q) failed: %v", tt.spec, err)
+			}
+			if l.Name != tt.name || l.Severity != tt.severity || l.Key != tt.key {
+				t.Errorf("ParseLevelSpec(%q) = %s/%d/%q, want %s/%d/%q", tt.spec, l.Name, l.Severity, l.Key, tt.name, tt.severity, tt.key)
+			}
+		})
+	}
+}
+
+func TestRegisterLevelRejectsBoundKeys(t *testing.T) {
+	for _, taken := range []string{"e", "x", "1", "6"} {
+		if _, err := RegisterLevel(Level{Name: "AUDIT", Key: taken}); err == nil {
+			t.Errorf("RegisterLevel with key %q worked, want an error", taken)
+		}
+	}
+
+	for _, l := range []Level{{Name: "WARN"}, {Name: "AUDIT", Aliases: []string{"err"}}} {
+		if _, err := RegisterLevel(l); err == nil {
+			t.Errorf("RegisterLevel(%s, aliases %v) worked, want an error", l.Name, l.Aliases)
+		}
+	}
+	if got := guessLevel("WARN disk nearly full"); got != WarnLevel {
+		t.Errorf("after a rejected WARN, guessLevel = %v, want WARN", got)
+	}
+}
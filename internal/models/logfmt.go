@@ -0,0 +1,74 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// logfmtParser reads logfmt lines, i.e. `time=... level=warn msg="..." user=bob`
+type logfmtParser struct{}
+
+func (logfmtParser) Name() string { return "logfmt" }
+
+func (p logfmtParser) Detect(line string) bool {
+	_, ok := p.Parse(line)
+	return ok
+}
+
+// Parse needs every word on the line to be a key=value pair, and there have to be at least
+// two of them, otherwise plenty of ordinary sentences with an `=` in them would qualify
+func (logfmtParser) Parse(text string) (Record, bool) {
+	if strings.Contains(text, "\n") {
+		return Record{}, false
+	}
+
+	pairs, ok := parseLogfmtPairs(text)
+	if !ok || len(pairs) < 2 {
+		return Record{}, false
+	}
+
+	return newStructuredRecord(pairs), true
+}
+
+// parseLogfmtPairs splits text made up entirely of logfmt key=value pairs
+func parseLogfmtPairs(text string) ([]Field, bool) {
+	var pairs []Field
+	rest := strings.TrimSpace(text)
+
+	for rest != "" {
+		// Keys run up to the `=`, and can't contain spaces or quotes
+		end := strings.IndexAny(rest, "= \t\"")
+		if end <= 0 || rest[end] != '=' {
+			return nil, false
+		}
+		key := rest[:end]
+		rest = rest[end+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, false
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end == -1 {
+				end = len(rest)
+			}
+			value = rest[:end]
+			rest = rest[end:]
+		}
+
+		// Pairs have to be separated by whitespace
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			return nil, false
+		}
+		rest = strings.TrimLeft(rest, " \t")
+
+		pairs = append(pairs, Field{Key: key, Value: value})
+	}
+
+	return pairs, true
+}
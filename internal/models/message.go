@@ -2,127 +2,177 @@ package models
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
-)
-
-// LogLevel represents typical log output levels
-type LogLevel int
+	"time"
 
-const (
-	InfoLevel  LogLevel = iota
-	WarnLevel  LogLevel = iota
-	ErrorLevel LogLevel = iota
-	DebugLevel LogLevel = iota
-	FatalLevel LogLevel = iota
-	OtherLevel LogLevel = iota
+	"github.com/charmbracelet/lipgloss/v2"
 )
 
-func (l LogLevel) String() string {
-	switch l {
-	case InfoLevel:
-		return "INFO"
-	case WarnLevel:
-		return "WARN"
-	case ErrorLevel:
-		return "ERROR"
-	case DebugLevel:
-		return "DEBUG"
-	case FatalLevel:
-		return "FATAL"
-	case OtherLevel:
-		return "OTHER"
-	}
-
-	return ""
-}
-
 // LogMessage is meant to represent a single logical log message
 // Typically denoted by an all-caps indicator near the start, such as INFO or WARN
 type LogMessage struct {
 	index   int
 	level   LogLevel
-	message string
+	message string // The record's full text, as it appears in the file
+	marker  bool   // Whether this is a note from campfire itself rather than a line from the file
+	source  int    // Which of the watched files the message is from
+
+	timestamp time.Time // When the record was logged, if it says. Zero if it doesn't
+
+	// Parts of a structured record (like a JSON or logfmt line), if it could be parsed as one
+	structured bool
+	time       string
+	text       string
+	fields     []Field
+	body       string // Lines after the first that the parser didn't cover
+}
+
+// Field is a key/value pair pulled out of a structured log record
+type Field struct {
+	Key   string
+	Value string
+}
+
+// renderOptions controls how messages get drawn, as opposed to which ones get shown
+type renderOptions struct {
+	hideFields bool           // Leave out the extra fields of structured records
+	search     *regexp.Regexp // Highlight matches of this, if it's set
+	tags       []string       // Rendered tag for each source, to mark which file messages are from. Nil if there's only one
 }
 
 func (m LogMessage) String() string {
-	var styleMsg string
-	switch m.level {
-	case InfoLevel:
-		styleMsg = infoStyle.Render(m.message)
-	case WarnLevel:
-		styleMsg = warnStyle.Render(m.message)
-	case ErrorLevel:
-		styleMsg = errorStyle.Render(m.message)
-	case DebugLevel:
-		styleMsg = debugStyle.Render(m.message)
-	case FatalLevel:
-		styleMsg = errorStyle.Render(m.message)
-	case OtherLevel:
-		styleMsg = m.message
+	return m.Render(renderOptions{})
+}
+
+// Render draws the message for the viewport, prefixed with its line number
+func (m LogMessage) Render(opts renderOptions) string {
+	var tag string
+	if opts.tags != nil {
+		tag = opts.tags[m.source] + " "
 	}
 
-	return fmt.Sprintf("%4d. %s", m.index+1, styleMsg)
+	if m.marker {
+		return tag + markerStyle.Render("────── "+m.message+" ──────")
+	}
+
+	style := m.level.Style()
+
+	// Structured records get laid out from their parts, and anything the parser didn't cover goes below
+	var first, rest string
+	if m.structured {
+		first, rest = m.renderStructured(style, opts), m.body
+	} else {
+		first, rest, _ = strings.Cut(m.message, "\n")
+		first = style.Render(first)
+	}
+
+	lines := []string{first}
+
+	// Style each continuation line on its own, and indent them to line up under the first
+	if rest != "" {
+		indent := strings.Repeat(" ", 6+lipgloss.Width(tag))
+		for _, line := range strings.Split(rest, "\n") {
+			lines = append(lines, indent+style.Render(line))
+		}
+	}
+
+	return fmt.Sprintf("%4d. %s%s", m.index+1, tag, strings.Join(lines, "\n"))
 }
 
-func NewLogMessage(i int, message string) LogMessage {
-	m := LogMessage{
-		index:   i,
-		message: message,
+// renderStructured lays out a parsed record as a readable line: time, level, message, then fields
+func (m LogMessage) renderStructured(style lipgloss.Style, opts renderOptions) string {
+	var parts []string
+
+	if m.time != "" {
+		parts = append(parts, timeStyle.Render(m.time))
+	}
+	if m.level != OtherLevel {
+		parts = append(parts, style.Render(fmt.Sprintf("%-5s", m.level)))
+	}
+	if m.text != "" {
+		parts = append(parts, style.Render(m.text))
 	}
 
-	switch {
-	case strings.Contains(message, "INFO"):
-		m.level = InfoLevel
+	if !opts.hideFields {
+		for _, field := range m.fields {
+			value := field.Value
+			if needsQuotes(value) {
+				value = strconv.Quote(value)
+			}
+			parts = append(parts, fieldKeyStyle.Render(field.Key+"=")+fieldValueStyle.Render(value))
+		}
+	}
 
-	case strings.Contains(message, "WARN"):
-		m.level = WarnLevel
+	return strings.Join(parts, " ")
+}
 
-	case strings.Contains(message, "ERRO"):
-		m.level = ErrorLevel
+// needsQuotes reports whether a field value would be ambiguous if it were shown without quotes.
+// Nested objects and arrays are left alone, since their brackets already delimit them
+func needsQuotes(value string) bool {
+	if strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[") {
+		return false
+	}
+
+	return value == "" || strings.ContainsAny(value, " \t\n\"=")
+}
+
+// NewLogMessage creates a message from a record's text, splitting it into parts with the given parser
+func NewLogMessage(i int, message string, parser Parser) LogMessage {
+	m := LogMessage{
+		index:   i,
+		message: message,
+	}
 
-	case strings.Contains(message, "DEBU"):
-		m.level = DebugLevel
+	rec, ok := parser.Parse(message)
 
-	case strings.Contains(message, "FATA"):
-		m.level = FatalLevel
+	// Line based formats only cover the first line of a record, with something like a stack trace after it
+	if first, rest, multiline := strings.Cut(message, "\n"); !ok && multiline {
+		if rec, ok = parser.Parse(first); ok {
+			m.body = rest
+		}
+	}
 
-	default:
-		m.level = OtherLevel
+	if !ok {
+		rec, _ = plainParser{}.Parse(message)
 	}
 
+	m.level = rec.Level
+	m.structured = !rec.Plain
+	m.time = rec.Time
+	m.text = rec.Message
+	m.fields = rec.Fields
+	m.timestamp = recordTimestamp(rec, message)
+
 	return m
 }
 
-type Filters struct {
-	ShowInfo  bool
-	ShowWarn  bool
-	ShowError bool
-	ShowDebug bool
-	ShowFatal bool
-	ShowOther bool
+// recordTimestamp gets when a record was logged, from the parsed time or else from the timestamp the line starts with
+func recordTimestamp(rec Record, message string) time.Time {
+	text := rec.Time
+	if text == "" {
+		text = strings.Trim(timestampPrefix.FindString(message), "[] ")
+	}
 
-	FilterText string
+	t, _ := parseTimestamp(text)
+	return t
 }
 
-func (f Filters) IncludeMessage(msg LogMessage) bool {
-	if f.FilterText != "" && !strings.Contains(msg.message, f.FilterText) {
-		return false
+// lineCount gets how many lines of the file the message covers. Markers aren't from the file, so they don't count
+func (m LogMessage) lineCount() int {
+	if m.marker {
+		return 0
 	}
 
-	switch msg.level {
-	case InfoLevel:
-		return f.ShowInfo
-	case WarnLevel:
-		return f.ShowWarn
-	case ErrorLevel:
-		return f.ShowError
-	case DebugLevel:
-		return f.ShowDebug
-	case FatalLevel:
-		return f.ShowFatal
-	case OtherLevel:
-		return f.ShowOther
-	}
+	return strings.Count(m.message, "\n") + 1
+}
 
-	return false
+// NewMarkerMessage creates a message that campfire inserts into the content itself, like a rotation notice
+func NewMarkerMessage(message string) LogMessage {
+	return LogMessage{
+		level:   OtherLevel,
+		message: message,
+		marker:  true,
+	}
 }
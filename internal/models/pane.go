@@ -0,0 +1,273 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/viewport"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// layout is how the panes are arranged
+type layout int
+
+const (
+	mergedLayout  layout = iota // One pane with every file, interleaved by time
+	tabsLayout                  // A pane for each file, showing one at a time
+	columnsLayout               // A pane for each file, side by side
+	rowsLayout                  // A pane for each file, stacked on top of each other
+)
+
+var layoutNames = []string{"merged", "tabs", "columns", "rows"}
+
+func (l layout) String() string {
+	return layoutNames[l]
+}
+
+// ParseLayout finds a layout by name
+func ParseLayout(name string) (layout, error) {
+	for i, n := range layoutNames {
+		if strings.EqualFold(name, n) {
+			return layout(i), nil
+		}
+	}
+
+	return mergedLayout, fmt.Errorf("unknown layout %q, expected one of: %s", name, strings.Join(layoutNames, ", "))
+}
+
+// pane is one view of the logs, with its own filters, search and scroll position
+type pane struct {
+	files   []*source    // The sources it shows
+	content []LogMessage // Records from its sources, merged by when they were logged
+
+	viewport viewport.Model
+	filters  Filters
+	render   renderOptions
+	search   search
+
+	viewLines   []string     // The lines last put in the viewport, before the selected search match is picked out
+	viewRecords []viewRecord // Where each record in viewLines starts
+
+	renders     int // How many renders have been started
+	shownRender int // Which render is in the viewport
+
+	anchor      windowAnchor // A record in a huge file to bring back into view once its window has been rendered
+	anchorAfter int          // The last render from before that window was loaded
+
+	seek      scroll // Which way huge files are being stepped through a window at a time, looking for records the filters let through
+	seekAfter int    // The last render from before the latest of those windows was loaded
+
+	following bool // Whether the viewport sticks to the bottom as new lines come in, like tail -f
+	unseen    int  // Lines that have come in since following was paused
+}
+
+// newPane makes a pane showing some of the sources, starting with the given filters
+func newPane(files []*source, filters Filters, render renderOptions) *pane {
+	p := &pane{
+		files:     files,
+		viewport:  viewport.New(),
+		filters:   filters,
+		render:    render,
+		following: true,
+		anchor:    noAnchor,
+	}
+	p.viewport.SoftWrap = true
+	p.content = mergeSources(files)
+
+	return p
+}
+
+// shows reports whether the pane shows records from a source
+func (p *pane) shows(src *source) bool {
+	for _, file := range p.files {
+		if file == src {
+			return true
+		}
+	}
+
+	return false
+}
+
+// title names the pane after its file, or how many files it has
+func (p *pane) title() string {
+	if len(p.files) == 1 {
+		return p.files[0].tag()
+	}
+
+	return fmt.Sprintf("%d files", len(p.files))
+}
+
+// sourceToggleCount gets how many sources can be toggled in the focused pane. None if it only shows one
+func (m model) sourceToggleCount() int {
+	if len(m.pane.files) < 2 {
+		return 0
+	}
+
+	return len(m.pane.files)
+}
+
+// visiblePanes gets the panes the layout puts on screen. With tabs, that's only the focused one
+func (m model) visiblePanes() []*pane {
+	switch m.layout {
+	case mergedLayout:
+		return []*pane{m.merged}
+	case tabsLayout:
+		return []*pane{m.pane}
+	}
+
+	return m.filePanes
+}
+
+// allPanes gets every pane, whether it's on screen or not
+func (m model) allPanes() []*pane {
+	return append([]*pane{m.merged}, m.filePanes...)
+}
+
+// setLayout rearranges the panes. The first time the files are split up, their panes start
+// with a copy of the merged pane's filters, apart from which files are hidden, since each pane
+// only has the one file and no way to show it again
+func (m *model) setLayout(l layout) {
+	if len(m.sources) < 2 {
+		l = mergedLayout
+	}
+
+	if l != mergedLayout && m.filePanes == nil {
+		for _, src := range m.sources {
+			render := m.merged.render
+			render.tags = nil
+			render.search = nil
+			filters := m.merged.filters
+			filters.hiddenSources = 0
+			m.filePanes = append(m.filePanes, newPane([]*source{src}, filters, render))
+		}
+	}
+
+	m.layout = l
+	if l == mergedLayout {
+		m.focusPane(m.merged)
+	} else if m.pane == m.merged {
+		m.focusPane(m.filePanes[0])
+	}
+}
+
+// switchPane moves focus forward or back through the file panes, wrapping around at either end
+func (m *model) switchPane(delta int) {
+	if m.layout == mergedLayout {
+		return
+	}
+
+	for i, p := range m.filePanes {
+		if p == m.pane {
+			m.focusPane(m.filePanes[(i+delta+len(m.filePanes))%len(m.filePanes)])
+			return
+		}
+	}
+}
+
+// focusPane makes keys act on a pane, and shows its filter in the text input
+func (m *model) focusPane(p *pane) {
+	m.pane = p
+	m.textInput.SetValue(p.filters.FilterText)
+}
+
+// layoutPanes sizes the visible panes to share the space between the header and footer.
+// The focused one gets a highlighted border when there's more than one on screen
+func (m *model) layoutPanes(width, height int) {
+	panes := m.visiblePanes()
+
+	for i, p := range panes {
+		w, h := width, height
+		switch m.layout {
+		case columnsLayout:
+			w = share(width, len(panes), i)
+		case rowsLayout:
+			h = share(height, len(panes), i)
+		}
+
+		style := viewportStyle.Width(w).Height(h)
+		if len(panes) > 1 && p == m.pane {
+			style = style.BorderForeground(titleColor)
+		}
+
+		p.viewport.SetWidth(w)
+		p.viewport.SetHeight(h)
+		p.viewport.Style = style
+		p.followBottom()
+	}
+}
+
+// share splits total into n nearly equal parts, and gets the size of the ith one. The first few get any remainder
+func share(total, n, i int) int {
+	size := total / n
+	if i < total%n {
+		size++
+	}
+
+	return size
+}
+
+// viewPanes draws the visible panes in their layout
+func (m model) viewPanes() string {
+	var views []string
+	for _, p := range m.visiblePanes() {
+		views = append(views, p.viewport.View())
+	}
+
+	if m.layout == columnsLayout {
+		return lipgloss.JoinHorizontal(lipgloss.Top, views...)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, views...)
+}
+
+// toggleIgnoreCase switches case sensitivity for the filter, exclusions and search all at once
+func (p *pane) toggleIgnoreCase() {
+	p.filters.ToggleIgnoreCase()
+
+	if p.search.text != "" {
+		p.search.set(p.search.text, p.filters.IgnoreCase)
+		p.search.jump = false
+		p.render.search = p.search.pattern
+	}
+}
+
+// pauseFollowing stops sticking to the bottom, unless the view is still there anyway
+func (p *pane) pauseFollowing() {
+	if p.following && !p.viewport.AtBottom() {
+		p.following = false
+		p.unseen = 0
+	}
+}
+
+// resumeFollowing jumps to the bottom and sticks there as new lines come in
+func (p *pane) resumeFollowing() {
+	p.following = true
+	p.unseen = 0
+	p.viewport.GotoBottom()
+}
+
+// lineOffset gets the viewport's y offset for the top of one of its lines.
+// Soft wrapped lines take up more than one row, counted the same way the viewport counts them
+func (p *pane) lineOffset(line int) int {
+	width := p.viewport.Width() - p.viewport.Style.GetHorizontalFrameSize()
+	if width <= 0 {
+		return line
+	}
+
+	offset := 0
+	for _, l := range p.viewLines[:min(line, len(p.viewLines))] {
+		offset += max(1, lipgloss.Width(l)/width)
+	}
+
+	return offset
+}
+
+// scrollToLine scrolls the viewport so one of its lines is in view, a little way down from the top
+func (p *pane) scrollToLine(line int) {
+	height := p.viewport.Height() - p.viewport.Style.GetVerticalFrameSize()
+	offset := p.lineOffset(line)
+
+	if offset < p.viewport.YOffset || offset >= p.viewport.YOffset+height {
+		p.viewport.SetYOffset(offset - height/3)
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFilePanesShowTheirFile(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	writeFile(t, a, "one\n")
+	writeFile(t, b, "two\n")
+
+	m := NewModel([]string{a, b}, Options{})
+	m.merged.filters.ToggleSource(1)
+	m.setLayout(tabsLayout)
+
+	if m.merged.filters.ShowsSource(1) {
+		t.Error("splitting the files up showed b.log in the merged pane again")
+	}
+	for i, p := range m.filePanes {
+		if !p.filters.ShowsSource(i) {
+			t.Errorf("pane %d hides its own file", i)
+		}
+	}
+}
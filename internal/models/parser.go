@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// sniffLines is how many lines from the top of a file are used to work out its format
+const sniffLines = 20
+
+// Parser turns the raw text of a log record into its parts. Implement it and pass it to
+// RegisterParser to teach campfire a new log format, or describe one with a regex using --custom-format
+type Parser interface {
+	// Name is what the format is called, and what gets passed to --format to force it
+	Name() string
+
+	// Detect reports whether a single line looks like it's in this format
+	Detect(line string) bool
+
+	// Parse splits a record into its parts. If the record turns out not to be in this
+	// format after all, it should return false so the record gets shown as plain text
+	Parse(text string) (Record, bool)
+}
+
+// Record holds the parts a Parser pulled out of a log record
+type Record struct {
+	Level   LogLevel
+	Time    string  // The record's timestamp, as written
+	Message string  // The human readable part of the record
+	Fields  []Field // Any other key/value pairs, in the order they were written
+
+	// Plain records are shown as their original text, rather than being laid out from their parts
+	Plain bool
+}
+
+// parsers holds every known format. Detection prefers the ones that come first, so registered
+// formats go ahead of the built in ones, in the order they were registered
+var parsers = []Parser{
+	jsonParser{},
+	logfmtParser{},
+	charmParser{},
+	syslogParser{},
+	accessParser{},
+	plainParser{},
+}
+
+// registered counts the formats added with RegisterParser, which sit at the front of parsers
+var registered int
+
+// RegisterParser adds a log format to the ones campfire knows about. Its name can't already be taken.
+// When a file could be in more than one format, the registered ones win over the built in ones
+func RegisterParser(p Parser) error {
+	if _, ok := LookupParser(p.Name()); ok {
+		return fmt.Errorf("there's already a format called %s", p.Name())
+	}
+
+	parsers = slices.Insert(parsers, registered, p)
+	registered++
+	return nil
+}
+
+// LookupParser finds a registered format by name, ignoring case
+func LookupParser(name string) (Parser, bool) {
+	for _, p := range parsers {
+		if strings.EqualFold(p.Name(), name) {
+			return p, true
+		}
+	}
+
+	return nil, false
+}
+
+// ParserNames lists the names of every registered format
+func ParserNames() []string {
+	names := make([]string, 0, len(parsers))
+	for _, p := range parsers {
+		names = append(names, p.Name())
+	}
+
+	return names
+}
+
+// detectParser sniffs the first few lines of a file to work out which format it's in.
+// A format has to match most of those lines to win, otherwise the file is treated as plain text
+func detectParser(lines []string) Parser {
+	var sample []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			sample = append(sample, line)
+		}
+		if len(sample) == sniffLines {
+			break
+		}
+	}
+
+	var best Parser = plainParser{}
+	bestScore := len(sample) / 2
+
+	for _, p := range parsers {
+		score := 0
+		for _, line := range sample {
+			if p.Detect(line) {
+				score++
+			}
+		}
+
+		if score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+
+	return best
+}
+
+// plainParser is the fallback for anything that isn't in a recognised format.
+// The record is shown as written, and its level is guessed from the text
+type plainParser struct{}
+
+func (plainParser) Name() string { return "plain" }
+
+// Detect never claims a line, since plain text is what's left when nothing else does
+func (plainParser) Detect(string) bool { return false }
+
+func (plainParser) Parse(text string) (Record, bool) {
+	first, _, _ := strings.Cut(text, "\n")
+
+	return Record{
+		Level:   guessLevel(first),
+		Message: text,
+		Plain:   true,
+	}, true
+}
@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestRegisteredParserDetectedFirst(t *testing.T) {
+	saved, savedCount := parsers, registered
+	t.Cleanup(func() { parsers, registered = saved, savedCount })
+
+	custom, err := ParseFormatSpec(`billing=^(?P<time>\S+) (?P<level>[A-Z]+) (?P<service>\w+): (?P<msg>.*)$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{
+		"2024-05-01T10:00:00Z INFO billing: invoice sent",
+		"2024-05-01T10:00:01Z WARN billing: card declined",
+	}
+	if got := detectParser(lines).Name(); got != "charm" {
+		t.Fatalf("before registering, detected %s, want charm", got)
+	}
+
+	if err := RegisterParser(custom); err != nil {
+		t.Fatal(err)
+	}
+	if got := detectParser(lines).Name(); got != "billing" {
+		t.Errorf("after registering, detected %s, want billing", got)
+	}
+
+	// Built in formats that the registered one doesn't match are still found
+	if got := detectParser([]string{`{"level":"info","msg":"started"}`}).Name(); got != "json" {
+		t.Errorf("detected %s for JSON, want json", got)
+	}
+
+	if err := RegisterParser(custom); err == nil {
+		t.Error("registering the same name twice worked, want an error")
+	}
+}
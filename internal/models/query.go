@@ -0,0 +1,442 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// A query is a boolean expression over log messages, typed into the filter box. For example:
+//
+//	level:error service:payments NOT "health check"
+//	(timeout OR refused) AND time:10:00..10:30
+//
+// Bare words and quoted phrases match the message's text, and terms next to each other must all match.
+// AND, OR and NOT have to be in capitals, so the lowercase words can still be searched for
+type queryNode interface {
+	matches(msg LogMessage, ignoreCase bool) bool
+}
+
+// queryError is a problem with a query, along with where in it the problem is
+type queryError struct {
+	pos int
+	msg string
+}
+
+func (e queryError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.msg, e.pos+1)
+}
+
+type andNode struct{ left, right queryNode }
+type orNode struct{ left, right queryNode }
+type notNode struct{ inner queryNode }
+
+func (n andNode) matches(msg LogMessage, ignoreCase bool) bool {
+	return n.left.matches(msg, ignoreCase) && n.right.matches(msg, ignoreCase)
+}
+
+func (n orNode) matches(msg LogMessage, ignoreCase bool) bool {
+	return n.left.matches(msg, ignoreCase) || n.right.matches(msg, ignoreCase)
+}
+
+func (n notNode) matches(msg LogMessage, ignoreCase bool) bool {
+	return !n.inner.matches(msg, ignoreCase)
+}
+
+// textNode matches messages containing a word or phrase
+type textNode struct{ text string }
+
+func (n textNode) matches(msg LogMessage, ignoreCase bool) bool {
+	return containsText(msg.message, n.text, ignoreCase)
+}
+
+// fieldNode matches messages with a field of the given value. Messages without the field
+// fall back to containing the term as it was typed, so `user:bob` still finds plain lines
+type fieldNode struct{ key, value, raw string }
+
+func (n fieldNode) matches(msg LogMessage, ignoreCase bool) bool {
+	if isOneOf(n.key, messageKeys) {
+		return containsText(msg.text, n.value, ignoreCase) || (!msg.structured && containsText(msg.message, n.value, ignoreCase))
+	}
+
+	for _, field := range msg.fields {
+		if strings.EqualFold(field.Key, n.key) {
+			return field.Value == n.value || (ignoreCase && strings.EqualFold(field.Value, n.value))
+		}
+	}
+
+	return containsText(msg.message, n.raw, ignoreCase)
+}
+
+// levelNode matches messages by level, either exactly or by comparing severities
+type levelNode struct {
+	op    string
+	level LogLevel
+}
+
+func (n levelNode) matches(msg LogMessage, _ bool) bool {
+	if n.op == "" {
+		return msg.level == n.level
+	}
+
+	// OTHER has no severity, so it's never above or below anything
+	if msg.level == OtherLevel {
+		return false
+	}
+
+	return compareOp(n.op, msg.level.Severity()-n.level.Severity())
+}
+
+// timeNode matches messages by timestamp, either against a bound or within an inclusive range
+type timeNode struct {
+	op       string
+	from, to time.Time
+}
+
+func (n timeNode) matches(msg LogMessage, _ bool) bool {
+	if msg.timestamp.IsZero() {
+		return false
+	}
+
+	if n.op == ".." {
+		return compareTimes(msg.timestamp, n.from) >= 0 && compareTimes(msg.timestamp, n.to) <= 0
+	}
+
+	return compareOp(n.op, compareTimes(msg.timestamp, n.from))
+}
+
+// compareOp applies a comparison operator to the result of comparing two things
+func compareOp(op string, diff int) bool {
+	switch op {
+	case ">":
+		return diff > 0
+	case ">=":
+		return diff >= 0
+	case "<":
+		return diff < 0
+	case "<=":
+		return diff <= 0
+	}
+
+	return diff == 0
+}
+
+// containsText reports whether s contains substr, optionally ignoring case
+func containsText(s, substr string, ignoreCase bool) bool {
+	if ignoreCase {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+
+	return strings.Contains(s, substr)
+}
+
+// ~~ Parsing ~~
+
+type tokenKind int
+
+const (
+	wordToken tokenKind = iota
+	phraseToken
+	fieldToken
+	andToken
+	orToken
+	notToken
+	openToken
+	closeToken
+	endToken
+)
+
+type token struct {
+	kind  tokenKind
+	pos   int
+	text  string // The word, phrase or field value
+	key   string // The field name, for field tokens
+	quote bool   // Whether a field's value was quoted
+}
+
+// fieldKeyPattern is what can come before the colon in a `key:value` term
+var fieldKeyPattern = regexp.MustCompile(`^[A-Za-z_@][\w.@-]*$`)
+
+// tokenize splits a query into words, phrases, fields, operators and parentheses
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == '(':
+			tokens = append(tokens, token{kind: openToken, pos: i})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, token{kind: closeToken, pos: i})
+			i++
+
+		case c == '"':
+			text, end, err := readPhrase(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: phraseToken, pos: i, text: text})
+			i = end
+
+		default:
+			start := i
+			for i < len(query) && !strings.ContainsRune(" \t()\"", rune(query[i])) {
+				i++
+			}
+			word := query[start:i]
+
+			switch word {
+			case "AND":
+				tokens = append(tokens, token{kind: andToken, pos: start})
+				continue
+			case "OR":
+				tokens = append(tokens, token{kind: orToken, pos: start})
+				continue
+			case "NOT":
+				tokens = append(tokens, token{kind: notToken, pos: start})
+				continue
+			}
+
+			key, value, isField := strings.Cut(word, ":")
+			if !isField || !fieldKeyPattern.MatchString(key) {
+				tokens = append(tokens, token{kind: wordToken, pos: start, text: word})
+				continue
+			}
+
+			// A quoted value right after the colon belongs to the field, as in `msg:"connection reset"`
+			quoted := false
+			if value == "" && i < len(query) && query[i] == '"' {
+				text, end, err := readPhrase(query, i)
+				if err != nil {
+					return nil, err
+				}
+				value, quoted, i = text, true, end
+			}
+
+			tokens = append(tokens, token{kind: fieldToken, pos: start, key: key, text: value, quote: quoted})
+		}
+	}
+
+	return append(tokens, token{kind: endToken, pos: len(query)}), nil
+}
+
+// readPhrase reads a quoted phrase starting at the quote at start, returning its text and where it ends
+func readPhrase(query string, start int) (string, int, error) {
+	end := strings.IndexByte(query[start+1:], '"')
+	if end < 0 {
+		return "", 0, queryError{start, "unclosed quote"}
+	}
+
+	return query[start+1 : start+1+end], start + end + 2, nil
+}
+
+// queryParser is a recursive descent parser over a query's tokens. From loosest to tightest binding:
+//
+//	or   = and { "OR" and }
+//	and  = not { ["AND"] not }
+//	not  = "NOT" not | atom
+//	atom = "(" or ")" | word | phrase | field
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseQuery parses the text of the filter box into a query.
+// An empty query comes back as nil, which the filters treat as matching everything
+func parseQuery(query string) (queryNode, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != endToken {
+		return nil, queryError{next.pos, "unexpected " + describeToken(next)}
+	}
+
+	return node, nil
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != endToken {
+		p.pos++
+	}
+	return t
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == orToken {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case andToken:
+			p.next()
+		case wordToken, phraseToken, fieldToken, notToken, openToken:
+			// Terms next to each other are joined with an implicit AND
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.peek().kind != notToken {
+		return p.parseAtom()
+	}
+
+	p.next()
+	inner, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	return notNode{inner}, nil
+}
+
+func (p *queryParser) parseAtom() (queryNode, error) {
+	t := p.next()
+
+	switch t.kind {
+	case wordToken, phraseToken:
+		return textNode{t.text}, nil
+
+	case fieldToken:
+		return newFieldNode(t)
+
+	case openToken:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != closeToken {
+			return nil, queryError{closing.pos, "expected ) but found " + describeToken(closing)}
+		}
+		return inner, nil
+	}
+
+	return nil, queryError{t.pos, "expected a term but found " + describeToken(t)}
+}
+
+// newFieldNode turns a `key:value` token into a node, which is special for the level and time keys
+func newFieldNode(t token) (queryNode, error) {
+	raw := t.key + ":" + t.text
+	if t.text == "" && !t.quote {
+		return nil, queryError{t.pos, "missing value for " + t.key}
+	}
+
+	switch {
+	case strings.EqualFold(t.key, "level"):
+		op, name := cutOperator(t.text)
+		level, ok := LookupLevel(name)
+		if !ok {
+			return nil, queryError{t.pos, fmt.Sprintf("unknown level %q", name)}
+		}
+		return levelNode{op, level}, nil
+
+	case strings.EqualFold(t.key, "time"):
+		return newTimeNode(t)
+	}
+
+	return fieldNode{key: t.key, value: t.text, raw: raw}, nil
+}
+
+// newTimeNode parses the value of a `time:` term, which is either a range like 10:00..10:30 or a bound like >=10:00
+func newTimeNode(t token) (queryNode, error) {
+	if from, to, isRange := strings.Cut(t.text, ".."); isRange {
+		start, ok := parseTimestamp(from)
+		if !ok {
+			return nil, queryError{t.pos, fmt.Sprintf("can't read time %q", from)}
+		}
+		end, ok := parseTimestamp(to)
+		if !ok {
+			return nil, queryError{t.pos, fmt.Sprintf("can't read time %q", to)}
+		}
+		return timeNode{op: "..", from: start, to: end}, nil
+	}
+
+	op, value := cutOperator(t.text)
+	bound, ok := parseTimestamp(value)
+	if !ok {
+		return nil, queryError{t.pos, fmt.Sprintf("can't read time %q", value)}
+	}
+
+	return timeNode{op: op, from: bound}, nil
+}
+
+// cutOperator splits a comparison operator off the front of a value, if it has one
+func cutOperator(value string) (string, string) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(value, op); ok {
+			return strings.TrimPrefix(op, "="), rest
+		}
+	}
+
+	return "", value
+}
+
+// describeToken names a token for error messages
+func describeToken(t token) string {
+	switch t.kind {
+	case andToken:
+		return "AND"
+	case orToken:
+		return "OR"
+	case notToken:
+		return "NOT"
+	case openToken:
+		return "("
+	case closeToken:
+		return ")"
+	case endToken:
+		return "end of query"
+	}
+
+	return fmt.Sprintf("%q", t.text)
+}
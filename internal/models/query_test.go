@@ -0,0 +1,176 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  queryNode
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"timeout", textNode{"timeout"}},
+		{`"health check"`, textNode{"health check"}},
+		{"and or not", andNode{andNode{textNode{"and"}, textNode{"or"}}, textNode{"not"}}},
+
+		// Operators, and how tightly they bind
+		{"a b", andNode{textNode{"a"}, textNode{"b"}}},
+		{"a AND b", andNode{textNode{"a"}, textNode{"b"}}},
+		{"a OR b", orNode{textNode{"a"}, textNode{"b"}}},
+		{"NOT a", notNode{textNode{"a"}}},
+		{"NOT NOT a", notNode{notNode{textNode{"a"}}}},
+		{"a OR b c", orNode{textNode{"a"}, andNode{textNode{"b"}, textNode{"c"}}}},
+		{"a b OR c", orNode{andNode{textNode{"a"}, textNode{"b"}}, textNode{"c"}}},
+		{"NOT a b", andNode{notNode{textNode{"a"}}, textNode{"b"}}},
+		{"(a OR b) c", andNode{orNode{textNode{"a"}, textNode{"b"}}, textNode{"c"}}},
+		{"NOT (a OR b)", notNode{orNode{textNode{"a"}, textNode{"b"}}}},
+		{"a OR b OR c", orNode{orNode{textNode{"a"}, textNode{"b"}}, textNode{"c"}}},
+
+		// Fields
+		{"service:payments", fieldNode{"service", "payments", "service:payments"}},
+		{`msg:"connection reset"`, fieldNode{"msg", "connection reset", "msg:connection reset"}},
+		{"http.status:500", fieldNode{"http.status", "500", "http.status:500"}},
+		{"level:error", levelNode{"", ErrorLevel}},
+		{"LEVEL:Warning", levelNode{"", WarnLevel}},
+		{"level:>=warn", levelNode{">=", WarnLevel}},
+		{"level:<info", levelNode{"<", InfoLevel}},
+		{"level:=debug", levelNode{"", DebugLevel}},
+
+		// Colons that aren't fields
+		{"10:30", textNode{"10:30"}},
+		{"http://example.com", fieldNode{"http", "//example.com", "http://example.com"}},
+		{":a", textNode{":a"}},
+
+		{
+			`level:error service:payments NOT "health check"`,
+			andNode{
+				andNode{levelNode{"", ErrorLevel}, fieldNode{"service", "payments", "service:payments"}},
+				notNode{textNode{"health check"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseQuery(%q) = %#v, want %#v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryTime(t *testing.T) {
+	ten, _ := parseTimestamp("10:00")
+	half, _ := parseTimestamp("10:30")
+
+	tests := []struct {
+		query string
+		want  queryNode
+	}{
+		{"time:10:00..10:30", timeNode{op: "..", from: ten, to: half}},
+		{"time:>=10:00", timeNode{op: ">=", from: ten}},
+		{"time:<10:30", timeNode{op: "<", from: half}},
+		{"time:10:00", timeNode{op: "", from: ten}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseQuery(%q) = %#v, want %#v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`"unclosed`, "unclosed quote at column 1"},
+		{`msg:"unclosed`, "unclosed quote at column 5"},
+		{"(a OR b", "expected ) but found end of query at column 8"},
+		{"a)", `unexpected ) at column 2`},
+		{"a OR", "expected a term but found end of query at column 5"},
+		{"AND a", "expected a term but found AND at column 1"},
+		{"NOT", "expected a term but found end of query at column 4"},
+		{"()", "expected a term but found ) at column 2"},
+		{"service:", "missing value for service at column 1"},
+		{"level:loud", `unknown level "loud" at column 1`},
+		{"time:soon", `can't read time "soon" at column 1`},
+		{"time:10:00..later", `can't read time "later" at column 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := parseQuery(tt.query)
+			if err == nil {
+				t.Fatalf("parseQuery(%q) = %#v, want an error", tt.query, node)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("parseQuery(%q) error = %q, want %q", tt.query, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	plain := NewLogMessage(0, "2024-05-01T10:15:00Z WARN payment for user:bob timed out", plainParser{})
+	structured := NewLogMessage(1, `{"level":"error","time":"2024-05-01T10:45:00Z","msg":"connection reset","service":"payments"}`, jsonParser{})
+	unlevelled := NewLogMessage(2, "just some text", plainParser{})
+
+	tests := []struct {
+		query      string
+		ignoreCase bool
+		want       []bool // Whether each of plain, structured and unlevelled match
+	}{
+		{"payment", false, []bool{true, true, false}},
+		{"PAYMENT", false, []bool{false, false, false}},
+		{"PAYMENT", true, []bool{true, true, false}},
+		{`"timed out"`, false, []bool{true, false, false}},
+		{"NOT payment", false, []bool{false, false, true}},
+		{"timed OR reset", false, []bool{true, true, false}},
+		{"timed reset", false, []bool{false, false, false}},
+
+		{"service:payments", false, []bool{false, true, false}},
+		{"service:Payments", true, []bool{false, true, false}},
+		{"user:bob", false, []bool{true, false, false}},
+		{`msg:"connection reset"`, false, []bool{false, true, false}},
+		{"msg:timed", false, []bool{true, false, false}},
+
+		{"level:warn", false, []bool{true, false, false}},
+		{"level:>=warn", false, []bool{true, true, false}},
+		{"level:>warn", false, []bool{false, true, false}},
+		{"level:<error", false, []bool{true, false, false}},
+
+		{"time:10:00..10:30", false, []bool{true, false, false}},
+		{"time:>10:30", false, []bool{false, true, false}},
+		{"level:error OR time:<10:30", false, []bool{true, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+
+			for i, msg := range []LogMessage{plain, structured, unlevelled} {
+				if got := node.matches(msg, tt.ignoreCase); got != tt.want[i] {
+					t.Errorf("%q matching %q = %t, want %t", tt.query, msg.message, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
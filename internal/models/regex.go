@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regexParser reads a format described by a regex, for in-house formats that campfire doesn't know.
+// Each named group becomes a key/value pair, so groups called level, time and msg (or any of the other
+// keys structured loggers use for those) fill in those parts, and any other groups become fields
+type regexParser struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// ParseFormatSpec reads a custom format from its command line form, NAME=REGEX
+func ParseFormatSpec(spec string) (Parser, error) {
+	name, expr, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || expr == "" {
+		return nil, fmt.Errorf("expected NAME=REGEX, got %q", spec)
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern for format %s: %w", name, err)
+	}
+
+	named := false
+	for _, group := range pattern.SubexpNames() {
+		named = named || group != ""
+	}
+	if !named {
+		return nil, fmt.Errorf("pattern for format %s needs at least one named group, like (?P<msg>.*)", name)
+	}
+
+	return regexParser{name: name, pattern: pattern}, nil
+}
+
+func (p regexParser) Name() string { return p.name }
+
+func (p regexParser) Detect(line string) bool {
+	return p.pattern.MatchString(line)
+}
+
+func (p regexParser) Parse(text string) (Record, bool) {
+	match := p.pattern.FindStringSubmatch(text)
+	if match == nil {
+		return Record{}, false
+	}
+
+	var pairs []Field
+	for i, group := range p.pattern.SubexpNames() {
+		if group != "" && match[i] != "" {
+			pairs = append(pairs, Field{group, match[i]})
+		}
+	}
+
+	return newStructuredRecord(pairs), true
+}
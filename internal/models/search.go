@@ -0,0 +1,126 @@
+package models
+
+import (
+	"regexp"
+
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// search is what's being looked for with `/`. Unlike the filter, it doesn't hide anything,
+// it just highlights the matches and lets the viewport jump between them
+type search struct {
+	text    string
+	pattern *regexp.Regexp // Nil if nothing's being searched for
+
+	matches []searchMatch // Where the matches are, as of the last viewport update
+	current int           // Which match is selected, or -1 to pick the first one from the top of the viewport
+	jump    bool          // Whether to scroll to the selected match once the matches are known
+}
+
+// searchMatch is where a match is in the viewport's lines, in cells
+type searchMatch struct {
+	line       int
+	start, end int
+}
+
+// set changes what's being searched for. An empty text stops searching
+func (s *search) set(text string, ignoreCase bool) {
+	s.text = text
+	s.pattern = nil
+	s.matches = nil
+	s.current = -1
+	s.jump = text != ""
+
+	if text == "" {
+		return
+	}
+
+	expr := regexp.QuoteMeta(text)
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	s.pattern = regexp.MustCompile(expr)
+}
+
+// step moves the selection forward or back by one match, wrapping around at either end
+func (s *search) step(delta int) {
+	if len(s.matches) == 0 {
+		return
+	}
+
+	s.current = (s.current + delta + len(s.matches)) % len(s.matches)
+	s.jump = true
+}
+
+// highlightMatches styles every match of pattern in the rendered lines.
+// The lines are already styled, so matching is done on their plain text and mapped back to cells
+func highlightMatches(lines []string, pattern *regexp.Regexp) ([]string, []searchMatch) {
+	var matches []searchMatch
+	highlighted := make([]string, len(lines))
+
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+
+		var ranges []lipgloss.Range
+		for _, loc := range pattern.FindAllStringIndex(plain, -1) {
+			start := ansi.StringWidth(plain[:loc[0]])
+			end := start + ansi.StringWidth(plain[loc[0]:loc[1]])
+			if start == end {
+				continue
+			}
+
+			ranges = append(ranges, lipgloss.NewRange(start, end, matchStyle))
+			matches = append(matches, searchMatch{i, start, end})
+		}
+
+		highlighted[i] = lipgloss.StyleRanges(line, ranges...)
+	}
+
+	return highlighted, matches
+}
+
+// showLines puts the latest lines in the viewport with the selected search match picked out.
+// It stays at the bottom if following, but scrolls to the selected match instead if it was just selected
+func (p *pane) showLines() {
+	s := &p.search
+
+	if len(s.matches) == 0 {
+		s.current = -1
+		p.viewport.SetContentLines(p.viewLines)
+		p.followBottom()
+		return
+	}
+
+	// Pick the first match that's on screen or below it, or wrap around to the first one
+	if s.current < 0 || s.current >= len(s.matches) {
+		s.current = 0
+		for i, match := range s.matches {
+			if p.lineOffset(match.line) >= p.viewport.YOffset {
+				s.current = i
+				break
+			}
+		}
+	}
+
+	selected := s.matches[s.current]
+	lines := make([]string, len(p.viewLines))
+	copy(lines, p.viewLines)
+	lines[selected.line] = lipgloss.StyleRanges(lines[selected.line], lipgloss.NewRange(selected.start, selected.end, selectedMatchStyle))
+
+	p.viewport.SetContentLines(lines)
+	p.followBottom()
+
+	if s.jump {
+		s.jump = false
+		p.scrollToLine(selected.line)
+		p.pauseFollowing()
+	}
+}
+
+// followBottom keeps the viewport at the bottom while following
+func (p *pane) followBottom() {
+	if p.following {
+		p.viewport.GotoBottom()
+	}
+}
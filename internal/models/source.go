@@ -0,0 +1,140 @@
+package models
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// MaxSources is how many files can be watched at once
+const MaxSources = 64
+
+// source is one of the files being watched, along with how much of it has been read
+type source struct {
+	id       int // Index into the model's sources
+	filename string
+	options  Options
+
+	content  []LogMessage // The file's records, in the order they were written
+	archived []LogMessage // Records from the files the log was rotated into, which go at the start of content
+	evicted  int          // How many lines have been dropped along with the oldest records, to stay under the max
+	dropped  int          // How many records have been sliced off the front of content's array since it was last copied
+
+	fileExists   bool
+	prevFileInfo fs.FileInfo
+
+	tail    tailer
+	parser  Parser // Format of the file, once it's known
+	reading bool   // Whether a checkFile command is still in flight
+	recheck bool   // Whether the file changed again while it was being read
+
+	watcher *watcher
+	huge    *hugeFile // Set if the file's too big to read whole, so it's read a window at a time
+
+	stream  *stream  // Where lines come from instead when the source is stdin or a command's output. Nil for files
+	done    bool     // Whether the stream has ended
+	command *command // The program whose output this is, if it is
+}
+
+// newSources sets up a source for each file, ready for their first check
+func newSources(filenames []string, opts Options) []*source {
+	sources := make([]*source, len(filenames))
+	for i, name := range filenames {
+		sources[i] = &source{
+			id:       i,
+			filename: name,
+			options:  opts,
+			parser:   opts.Parser,
+			reading:  true, // Init performs the first check
+		}
+
+		if name == StdinName {
+			sources[i].stream = newStream(os.Stdin)
+		}
+	}
+
+	return sources
+}
+
+// tag is the short name a source's records are marked with when there's more than one source
+func (s *source) tag() string {
+	if s.command != nil {
+		return s.filename
+	}
+	if s.stream != nil {
+		return "stdin"
+	}
+
+	return filepath.Base(s.filename)
+}
+
+// needsPolling reports whether the file has to be polled for changes, because there's no watcher telling campfire
+func (s *source) needsPolling() bool {
+	return s.stream == nil && s.command == nil && s.watcher == nil
+}
+
+// tagStyle gets the style for the source's tag. A command's stderr stands out in the error color
+func (s *source) tagStyle() lipgloss.Style {
+	if s.command != nil && s == s.command.stderr {
+		return stderrTagStyle
+	}
+
+	return sourceStyle(s.id)
+}
+
+// isStream reports whether the source is a stream, like stdin or a command's output, rather than a file
+func (s *source) isStream() bool {
+	return s.command != nil || s.filename == StdinName
+}
+
+// size gets how big the file is, or how much has come through the stream so far
+func (s *source) size() int64 {
+	if s.isStream() {
+		return s.tail.offset
+	}
+
+	return s.prevFileInfo.Size()
+}
+
+// mergeSources interleaves the records of every source by when they were logged. Records without
+// a timestamp stay right after the record before them in their own file, and ties keep the sources' order
+func mergeSources(sources []*source) []LogMessage {
+	if len(sources) == 1 {
+		return sources[0].content
+	}
+
+	total := 0
+	for _, s := range sources {
+		total += len(s.content)
+	}
+
+	merged := make([]LogMessage, 0, total)
+	next := make([]int, len(sources)) // Index of the next record to take from each source
+
+	for len(merged) < total {
+		pick := -1
+		for i, s := range sources {
+			if next[i] == len(s.content) {
+				continue
+			}
+
+			// Undated records go as soon as they come up, since they belong with whatever came before them
+			msg := s.content[next[i]]
+			if msg.timestamp.IsZero() {
+				pick = i
+				break
+			}
+
+			if pick < 0 || compareTimes(msg.timestamp, sources[pick].content[next[pick]].timestamp) < 0 {
+				pick = i
+			}
+		}
+
+		merged = append(merged, sources[pick].content[next[pick]])
+		next[pick]++
+	}
+
+	return merged
+}
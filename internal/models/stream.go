@@ -0,0 +1,86 @@
+package models
+
+import (
+	"io"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// StdinName is the filename that means reading from stdin instead, like from a pipe
+const StdinName = "-"
+
+// streamChunkSize is how much gets read from the stream at a time
+const streamChunkSize = 32 * 1024
+
+// maxStreamRead caps how much of what's been buffered gets handed over in one message, so a
+// fast producer still gets shown a piece at a time
+const maxStreamRead = 4 * 1024 * 1024
+
+// stream reads a pipe in the background, since reads block until the producer writes something.
+// Chunks queue up until campfire is ready for them, and the channel closes once the pipe does
+type stream struct {
+	chunks chan []byte
+	err    error // Why reading stopped, which is io.EOF when the producer exits. Only set once chunks is closed
+
+	stopped chan struct{} // Closed once nothing's going to read the chunks any more
+	stop    sync.Once
+}
+
+// newStream starts reading from r
+func newStream(r io.Reader) *stream {
+	s := &stream{chunks: make(chan []byte, 64), stopped: make(chan struct{})}
+	go s.run(r)
+
+	return s
+}
+
+// close stops the stream once nothing is going to read it, like when the program writing it is restarted.
+// Reading ends as soon as the pipe does, or right away if it's stuck waiting for room in the queue
+func (s *stream) close() {
+	s.stop.Do(func() { close(s.stopped) })
+}
+
+func (s *stream) run(r io.Reader) {
+	defer close(s.chunks)
+
+	for {
+		buf := make([]byte, streamChunkSize)
+		n, err := r.Read(buf)
+		if n > 0 {
+			select {
+			case s.chunks <- buf[:n]:
+			case <-s.stopped:
+				return
+			}
+		}
+		if err != nil {
+			s.err = err
+			return
+		}
+	}
+}
+
+// readStream waits for the next chunk of a stream, then gathers up whatever else is already waiting
+func readStream(src int, s *stream) tea.Cmd {
+	return func() tea.Msg {
+		content, ok := <-s.chunks
+		if !ok {
+			return streamReadMsg{source: src, stream: s, err: s.err}
+		}
+
+		for len(content) < maxStreamRead {
+			select {
+			case chunk, ok := <-s.chunks:
+				if !ok {
+					return streamReadMsg{source: src, stream: s, content: content, err: s.err}
+				}
+				content = append(content, chunk...)
+			default:
+				return streamReadMsg{source: src, stream: s, content: content}
+			}
+		}
+
+		return streamReadMsg{source: src, stream: s, content: content}
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"strings"
+)
+
+// Keys that common structured loggers (zap, zerolog, slog, logrus) use for the core parts of a record
+var (
+	levelKeys   = []string{"level", "lvl", "severity"}
+	timeKeys    = []string{"time", "ts", "timestamp"}
+	messageKeys = []string{"msg", "message"}
+)
+
+// newStructuredRecord builds a record out of key/value pairs. The first level, time and
+// message keys are pulled out, and everything else is kept as fields in their original order
+func newStructuredRecord(pairs []Field) Record {
+	r := Record{Level: OtherLevel}
+
+	var foundLevel, foundTime, foundMessage bool
+	for _, pair := range pairs {
+		switch {
+		case !foundLevel && isOneOf(pair.Key, levelKeys):
+			foundLevel = true
+			r.Level = levelFromName(pair.Value)
+		case !foundTime && isOneOf(pair.Key, timeKeys):
+			foundTime = true
+			r.Time = pair.Value
+		case !foundMessage && isOneOf(pair.Key, messageKeys):
+			foundMessage = true
+			r.Message = pair.Value
+		default:
+			r.Fields = append(r.Fields, pair)
+		}
+	}
+
+	return r
+}
+
+// isOneOf reports whether key is one of keys, ignoring case
+func isOneOf(key string, keys []string) bool {
+	for _, k := range keys {
+		if strings.EqualFold(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,9 +1,6 @@
 package models
 
 import (
-	"fmt"
-	"strings"
-
 	"github.com/charmbracelet/lipgloss/v2"
 	"github.com/charmbracelet/lipgloss/v2/compat"
 )
@@ -13,14 +10,34 @@ var (
 	titleColor    = compat.AdaptiveColor{Light: lipgloss.Color("#dd7878"), Dark: lipgloss.Color("#f2d5cf")}
 	filenameColor = compat.AdaptiveColor{Light: lipgloss.Color("#fe640b"), Dark: lipgloss.Color("#ef9f76")}
 	statsColor    = compat.AdaptiveColor{Light: lipgloss.Color("#7c7f93"), Dark: lipgloss.Color("#737994")}
+	valueColor    = compat.AdaptiveColor{Light: lipgloss.Color("#209fb5"), Dark: lipgloss.Color("#85c1dc")}
 
 	// LogLevel colors
-	infoColor  = compat.AdaptiveColor{Light: lipgloss.Color("#40a02b"), Dark: lipgloss.Color("#a6d189")}
-	warnColor  = compat.AdaptiveColor{Light: lipgloss.Color("#df8e1d"), Dark: lipgloss.Color("#e5c890")}
-	errorColor = compat.AdaptiveColor{Light: lipgloss.Color("#d20f39"), Dark: lipgloss.Color("#e78284")}
-	debugColor = compat.AdaptiveColor{Light: lipgloss.Color("#8839ef"), Dark: lipgloss.Color("#ca9ee6")}
+	traceColor    = compat.AdaptiveColor{Light: lipgloss.Color("#179299"), Dark: lipgloss.Color("#81c8be")}
+	debugColor    = compat.AdaptiveColor{Light: lipgloss.Color("#8839ef"), Dark: lipgloss.Color("#ca9ee6")}
+	infoColor     = compat.AdaptiveColor{Light: lipgloss.Color("#40a02b"), Dark: lipgloss.Color("#a6d189")}
+	noticeColor   = compat.AdaptiveColor{Light: lipgloss.Color("#1e66f5"), Dark: lipgloss.Color("#8caaee")}
+	warnColor     = compat.AdaptiveColor{Light: lipgloss.Color("#df8e1d"), Dark: lipgloss.Color("#e5c890")}
+	errorColor    = compat.AdaptiveColor{Light: lipgloss.Color("#d20f39"), Dark: lipgloss.Color("#e78284")}
+	criticalColor = compat.AdaptiveColor{Light: lipgloss.Color("#e64553"), Dark: lipgloss.Color("#ea999c")}
+	panicColor    = compat.AdaptiveColor{Light: lipgloss.Color("#eff1f5"), Dark: lipgloss.Color("#303446")}
+
+	// Source tag colors, reused in order when there are more sources than colors
+	sourceColors = []compat.AdaptiveColor{
+		{Light: lipgloss.Color("#04a5e5"), Dark: lipgloss.Color("#99d1db")},
+		{Light: lipgloss.Color("#ea76cb"), Dark: lipgloss.Color("#f4b8e4")},
+		{Light: lipgloss.Color("#7287fd"), Dark: lipgloss.Color("#babbf1")},
+		{Light: lipgloss.Color("#dc8a78"), Dark: lipgloss.Color("#f2d5cf")},
+		{Light: lipgloss.Color("#40a02b"), Dark: lipgloss.Color("#a6d189")},
+		{Light: lipgloss.Color("#fe640b"), Dark: lipgloss.Color("#ef9f76")},
+	}
 )
 
+// sourceStyle gets the style for a source's tag
+func sourceStyle(source int) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(sourceColors[source%len(sourceColors)]).Bold(true)
+}
+
 var (
 	titleStyle = lipgloss.NewStyle().
 			Foreground(titleColor).
@@ -42,9 +59,75 @@ var (
 			Align(lipgloss.Left, lipgloss.Top).
 			Border(lipgloss.RoundedBorder())
 
+	activeTabStyle = lipgloss.NewStyle().
+			Foreground(titleColor).
+			Bold(true).
+			Underline(true)
+
+	tabStyle = lipgloss.NewStyle().
+			Foreground(statsColor)
+
+	filterErrorStyle = lipgloss.NewStyle().
+				Foreground(errorColor)
+
+	followingStyle = lipgloss.NewStyle().
+			Foreground(infoColor)
+
+	pausedStyle = lipgloss.NewStyle().
+			Foreground(warnColor).
+			Bold(true)
+
+	stderrTagStyle = lipgloss.NewStyle().
+			Foreground(errorColor).
+			Bold(true)
+
+	eofStyle = lipgloss.NewStyle().
+			Foreground(statsColor).
+			Bold(true)
+
+	matchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#303446")).
+			Background(warnColor)
+
+	selectedMatchStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#303446")).
+				Background(filenameColor).
+				Bold(true)
+
+	contextStyle = lipgloss.NewStyle().
+			Foreground(statsColor).
+			Faint(true)
+
+	contextSeparatorStyle = lipgloss.NewStyle().
+				Foreground(statsColor)
+
+	chipStyle = lipgloss.NewStyle().
+			Foreground(statsColor).
+			Italic(true)
+
+	markerStyle = lipgloss.NewStyle().
+			Foreground(statsColor).
+			Bold(true)
+
+	timeStyle = lipgloss.NewStyle().
+			Foreground(statsColor)
+
+	fieldKeyStyle = lipgloss.NewStyle().
+			Foreground(statsColor).
+			Italic(true)
+
+	fieldValueStyle = lipgloss.NewStyle().
+			Foreground(valueColor)
+
+	traceStyle = lipgloss.NewStyle().
+			Foreground(traceColor)
+
 	infoStyle = lipgloss.NewStyle().
 			Foreground(infoColor)
 
+	noticeStyle = lipgloss.NewStyle().
+			Foreground(noticeColor)
+
 	warnStyle = lipgloss.NewStyle().
 			Foreground(warnColor).
 			Italic(true)
@@ -53,44 +136,20 @@ var (
 			Foreground(errorColor).
 			Bold(true)
 
+	criticalStyle = lipgloss.NewStyle().
+			Foreground(criticalColor).
+			Bold(true)
+
+	fatalStyle = lipgloss.NewStyle().
+			Foreground(errorColor).
+			Bold(true).
+			Underline(true)
+
+	panicStyle = lipgloss.NewStyle().
+			Foreground(panicColor).
+			Background(errorColor).
+			Bold(true)
+
 	debugStyle = lipgloss.NewStyle().
 			Foreground(debugColor)
 )
-
-func StyleMessage(line string, lineNum int, filters Filters) string {
-	var styleMsg string
-	switch {
-	case strings.Contains(line, "INFO"):
-		if !filters.ShowInfo {
-			return ""
-		} else {
-			styleMsg = infoStyle.Render(line)
-		}
-
-	case strings.Contains(line, "WARN"):
-		if !filters.ShowWarn {
-			return ""
-		} else {
-			styleMsg = warnStyle.Render(line)
-		}
-
-	case strings.Contains(line, "ERRO"):
-		if !filters.ShowError {
-			return ""
-		} else {
-			styleMsg = errorStyle.Render(line)
-		}
-
-	case strings.Contains(line, "DEBU"):
-		if !filters.ShowDebug {
-			return ""
-		} else {
-			styleMsg = debugStyle.Render(line)
-		}
-
-	default:
-		styleMsg = line
-	}
-
-	return fmt.Sprintf("%4d. %s", lineNum+1, styleMsg)
-}
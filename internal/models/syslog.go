@@ -0,0 +1,75 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var (
+	// BSD style syslog (RFC 3164), i.e. `<34>Oct 11 22:14:15 host app[123]: message`, where the priority is optional
+	bsdSyslogPattern = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (\S+) ([^:\[\s]+)(?:\[(\d+)\])?: ?(.*)$`)
+
+	// Modern syslog (RFC 5424), i.e. `<34>1 2003-10-11T22:14:15.003Z host app 123 ID47 [data] message`
+	syslogPattern = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (?:-|\[.*?\]) ?(.*)$`)
+)
+
+// syslogParser reads syslog lines, in both the BSD and the modern format
+type syslogParser struct{}
+
+func (syslogParser) Name() string { return "syslog" }
+
+func (syslogParser) Detect(line string) bool {
+	return bsdSyslogPattern.MatchString(line) || syslogPattern.MatchString(line)
+}
+
+func (syslogParser) Parse(text string) (Record, bool) {
+	var priority, timestamp, host, app, pid, message string
+
+	if match := syslogPattern.FindStringSubmatch(text); match != nil {
+		priority, timestamp, host, app, pid, message = match[1], match[2], match[3], match[4], match[5], match[7]
+	} else if match := bsdSyslogPattern.FindStringSubmatch(text); match != nil {
+		priority, timestamp, host, app, pid, message = match[1], match[2], match[3], match[4], match[5], match[6]
+	} else {
+		return Record{}, false
+	}
+
+	r := Record{
+		Level:   syslogLevel(priority, message),
+		Time:    timestamp,
+		Message: message,
+	}
+
+	for _, field := range []Field{{"host", host}, {"app", app}, {"pid", pid}} {
+		if field.Value != "" && field.Value != "-" {
+			r.Fields = append(r.Fields, field)
+		}
+	}
+
+	return r, true
+}
+
+// syslogLevel takes the level from the severity in the priority, if the line has one.
+// Otherwise, it's guessed from the message like it would be for plain text
+func syslogLevel(priority, message string) LogLevel {
+	pri, err := strconv.Atoi(priority)
+	if err != nil {
+		return guessLevel(message)
+	}
+
+	switch pri % 8 {
+	case 0, 1: // Emergency, alert
+		return FatalLevel
+	case 2:
+		return CriticalLevel
+	case 3:
+		return ErrorLevel
+	case 4:
+		return WarnLevel
+	case 5:
+		return NoticeLevel
+	case 6:
+		return InfoLevel
+	}
+
+	return DebugLevel
+}
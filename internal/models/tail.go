@@ -0,0 +1,249 @@
+package models
+
+import (
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// rotation describes how the watched file changed underneath campfire between checks
+type rotation int
+
+const (
+	notRotated        rotation = iota
+	replacedRotation           // The path points at a different file now, like logrotate's `create`
+	truncatedRotation          // The same file got shorter, like logrotate's `copytruncate`
+)
+
+func (r rotation) String() string {
+	switch r {
+	case replacedRotation:
+		return "file replaced"
+	case truncatedRotation:
+		return "file truncated"
+	}
+
+	return ""
+}
+
+// detectRotation compares the file's current info against what it looked like at the last check
+func detectRotation(prev, info fs.FileInfo, offset int64) rotation {
+	switch {
+	case prev == nil:
+		return notRotated
+	case !os.SameFile(prev, info):
+		return replacedRotation
+	case info.Size() < offset:
+		return truncatedRotation
+	}
+
+	return notRotated
+}
+
+// tailer remembers how much of the watched file has already been consumed,
+// so that each check only has to read the bytes appended since the last one
+type tailer struct {
+	offset  int64   // Offset of the first byte that hasn't been read yet
+	lines   int     // Number of complete lines read from the current file
+	partial string  // Trailing text that hasn't been terminated by a newline yet
+	open    *record // The latest record, which could still get continuation lines
+	shown   int     // Number of messages at the end of the content that are still provisional
+
+	// While the format is still being worked out, the lines it's guessed from, the guess so far,
+	// and how many messages at the end of the content were parsed with that guess
+	sample  []string
+	guess   Parser
+	guessed int
+}
+
+// appendContent splits newly read bytes into lines, groups them into records, and appends those to
+// the source's content. The latest record and any unterminated line are shown right away, but they're
+// provisional, and get rebuilt once more of the file arrives
+func (s *source) appendContent(content []byte) {
+	// Clipped so the provisional messages get replaced in a new array, since the old one could still be getting rendered
+	s.content = slices.Clip(s.content[:len(s.content)-s.tail.shown])
+	s.tail.shown = 0
+
+	now := time.Now()
+	lines := strings.Split(s.tail.partial+string(content), "\n")
+	s.tail.partial = lines[len(lines)-1]
+
+	// Work out the file's format from the first lines read, unless it was given up front
+	if s.parser == nil {
+		s.sniff(lines[:len(lines)-1])
+	}
+	parser := s.currentParser()
+
+	for _, line := range lines[:len(lines)-1] {
+		if s.continuesRecord(s.tail.open, line) {
+			s.tail.open.lines = append(s.tail.open.lines, line)
+		} else {
+			// Anything new means the open record is done
+			if s.tail.open != nil {
+				s.content = append(s.content, s.message(*s.tail.open, parser))
+				if s.parser == nil {
+					s.tail.guessed++
+				}
+			}
+			s.tail.open = &record{start: s.tail.lines, lines: []string{line}, arrived: now}
+		}
+
+		s.tail.lines++
+	}
+
+	// Show whatever is still open
+	var pending []record
+	if s.tail.open != nil {
+		pending = append(pending, *s.tail.open)
+	}
+
+	if s.tail.partial != "" {
+		if s.continuesRecord(s.tail.open, s.tail.partial) {
+			pending[0] = pending[0].with(s.tail.partial)
+		} else {
+			pending = append(pending, record{start: s.tail.lines, lines: []string{s.tail.partial}, arrived: now})
+		}
+	}
+
+	for _, r := range pending {
+		s.content = append(s.content, s.message(r, parser))
+		s.tail.shown++
+	}
+
+	s.trim()
+}
+
+// sniff works out the file's format from its first non-blank lines. Until there are enough of them to be sure,
+// the format is a guess, and if a later line changes the guess, the records parsed so far are parsed again
+func (s *source) sniff(lines []string) {
+	for _, line := range lines {
+		if len(s.tail.sample) < sniffLines && strings.TrimSpace(line) != "" {
+			s.tail.sample = append(s.tail.sample, line)
+		}
+	}
+
+	guess := detectParser(s.tail.sample)
+	if s.tail.guessed > 0 && guess.Name() != s.currentParser().Name() {
+		s.reparse(guess)
+	}
+	s.tail.guess = guess
+
+	if len(s.tail.sample) == sniffLines {
+		s.parser = guess
+		s.tail.sample = nil
+	}
+}
+
+// reparse parses the messages read with the guessed format again, in a new array since the old one could still be getting rendered
+func (s *source) reparse(parser Parser) {
+	guessed := min(s.tail.guessed, len(s.content))
+	s.content = slices.Clone(s.content)
+
+	for i := len(s.content) - guessed; i < len(s.content); i++ {
+		old := s.content[i]
+		s.content[i] = NewLogMessage(old.index, old.message, parser)
+		s.content[i].source = old.source
+
+		// Keep when a command's output came in, if it doesn't have a timestamp of its own
+		if s.command != nil && s.content[i].timestamp.IsZero() {
+			s.content[i].timestamp = old.timestamp
+		}
+	}
+}
+
+// trim drops the oldest records once they add up to more lines than the max, so a long tail doesn't keep growing.
+// Records are only dropped whole, and the newest one and the provisional ones at the end always stay, since those
+// get replaced as more of the file comes in. The oldest records are sliced off rather than copied every time,
+// and what's kept only gets copied to a new array once more has been dropped than kept, so the old one can be
+// freed once any render still using it is done
+func (s *source) trim() {
+	limit := s.options.MaxLines
+	if limit <= 0 {
+		return
+	}
+
+	// Work back from the newest record to find the oldest one that still fits
+	kept, cut := 0, len(s.content)
+	for cut > 0 {
+		lines := s.content[cut-1].lineCount()
+		if kept > 0 && kept+lines > limit && cut <= len(s.content)-s.tail.shown {
+			break
+		}
+		kept += lines
+		cut--
+	}
+	if cut == 0 {
+		return
+	}
+
+	for _, msg := range s.content[:cut] {
+		s.evicted += msg.lineCount()
+	}
+	s.content = s.content[cut:]
+	s.archived = s.archived[min(cut, len(s.archived)):]
+
+	s.dropped += cut
+	if s.dropped > len(s.content) {
+		s.content = slices.Clone(s.content)
+		s.archived = slices.Clone(s.archived)
+		s.dropped = 0
+	}
+}
+
+// startOver prepares for reading the file again from the top. After a rotation, a marker
+// is left behind, along with the old lines if campfire was asked to keep them
+func (s *source) startOver(r rotation) {
+	s.tail = tailer{}
+
+	if s.huge != nil {
+		s.huge.close()
+		s.huge = nil
+	}
+
+	s.parser = s.options.Parser
+
+	// Records from the files the log was rotated into stay put, since those files haven't changed
+	if r == notRotated || !s.options.KeepRotated {
+		s.content = slices.Clip(s.archived)
+		s.evicted, s.dropped = 0, 0
+	}
+
+	if r != notRotated {
+		s.content = append(s.content, s.marker("log rotated at "+time.Now().Format("15:04")+" ("+r.String()+")"))
+	}
+}
+
+// marker makes a marker message belonging to this source
+func (s *source) marker(text string) LogMessage {
+	marker := NewMarkerMessage(text)
+	marker.source = s.id
+	return marker
+}
+
+// message turns a record from this source into a LogMessage
+func (s *source) message(r record, parser Parser) LogMessage {
+	msg := r.message(parser)
+	msg.source = s.id
+
+	// Programs rarely timestamp their output, so it's dated by when it came in. That keeps stdout and stderr in order
+	if s.command != nil && msg.timestamp.IsZero() {
+		msg.timestamp = r.arrived
+	}
+
+	return msg
+}
+
+// currentParser gets the parser for the file's format. Until the format is known, the best guess so far is
+// used, and before there's even that, lines are treated as plain text
+func (s *source) currentParser() Parser {
+	switch {
+	case s.parser != nil:
+		return s.parser
+	case s.tail.guess != nil:
+		return s.tail.guess
+	}
+
+	return plainParser{}
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectRotation(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	other := filepath.Join(dir, "other.log")
+
+	writeFile(t, name, "one\ntwo\n")
+	writeFile(t, other, "one\ntwo\nthree\n")
+	original := stat(t, name)
+	replacement := stat(t, other)
+
+	tests := []struct {
+		name   string
+		prev   fs.FileInfo
+		info   fs.FileInfo
+		offset int64
+		want   rotation
+	}{
+		{"first check", nil, original, 0, notRotated},
+		{"unchanged", original, original, original.Size(), notRotated},
+		{"grown", original, original, original.Size() - 4, notRotated},
+		{"truncated", original, original, original.Size() + 10, truncatedRotation},
+		{"replaced", original, replacement, original.Size(), replacedRotation},
+		{"replaced and bigger", original, replacement, 0, replacedRotation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectRotation(tt.prev, tt.info, tt.offset); got != tt.want {
+				t.Errorf("detectRotation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// A logrotate create often gets noticed while the old file's been renamed and the new one isn't there yet
+func TestRotationWhileFileGone(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		name := filepath.Join(t.TempDir(), "app.log")
+		writeFile(t, name, "INFO old\n")
+
+		m := NewModel([]string{name}, Options{KeepRotated: keep})
+		src := m.sources[0]
+		m.Update(checkFile(0, name, nil, 0, 0)())
+
+		if err := os.Rename(name, name+".1"); err != nil {
+			t.Fatal(err)
+		}
+		m.Update(src.check()())
+		if src.prevFileInfo == nil {
+			t.Fatalf("keep=%v: previous file info dropped when the file went away", keep)
+		}
+
+		writeFile(t, name, "INFO new\n")
+		m.Update(src.check()())
+
+		var messages []string
+		for _, msg := range src.content {
+			messages = append(messages, msg.message)
+		}
+		got := strings.Join(messages, "|")
+
+		if !strings.Contains(got, "log rotated at") {
+			t.Errorf("keep=%v: no rotation marker in %q", keep, got)
+		}
+		if strings.Contains(got, "INFO old") != keep {
+			t.Errorf("keep=%v: old lines kept = %v in %q", keep, !keep, got)
+		}
+		if !strings.HasSuffix(got, "INFO new") {
+			t.Errorf("keep=%v: new lines missing from %q", keep, got)
+		}
+	}
+}
+
+func TestFormatSniffedAcrossReads(t *testing.T) {
+	s := &source{filename: "app.log"}
+
+	s.appendContent([]byte("starting up\n"))
+	if got := s.currentParser().Name(); got != "plain" {
+		t.Fatalf("format after one line = %s, want plain", got)
+	}
+
+	s.appendContent([]byte(strings.Repeat(`{"level":"warn","msg":"slow"}`+"\n", 3)))
+	if got := s.currentParser().Name(); got != "json" {
+		t.Fatalf("format after the JSON lines = %s, want json", got)
+	}
+	if s.parser != nil {
+		t.Errorf("format settled after %d lines, want it to wait for %d", len(s.tail.sample), sniffLines)
+	}
+	if !s.content[1].structured || s.content[1].level != WarnLevel {
+		t.Errorf("first JSON record wasn't parsed as JSON: %+v", s.content[1])
+	}
+
+	s.appendContent([]byte(strings.Repeat(`{"level":"info","msg":"ok"}`+"\n", sniffLines)))
+	if s.parser == nil || s.parser.Name() != "json" {
+		t.Errorf("format not settled as json after %d lines", sniffLines+4)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		content     string
+		then        string // More of the file, once the content's records are done
+		wantFirst   string // The oldest record kept
+		wantEvicted int
+	}{
+		{"under the max", 10, "one\ntwo\n", "three\n", "one", 0},
+		{"single lines", 2, "one\ntwo\nthree\n", "four\n", "three", 2},
+		{"records dropped whole", 5, "one\ntwo\n  body\n  body\nthree\n", "four\n", "two\n  body\n  body", 1},
+		{"records not split", 3, "one\ntwo\n  body\n  body\nthree\n", "four\n", "three", 4},
+		{"newest record bigger than the max", 2, "one\ntwo\n", "three\n  body\n  body\n", "three\n  body\n  body", 2},
+		{"no max", 0, "one\ntwo\n", "three\n", "one", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &source{options: Options{MaxLines: tt.limit, Parser: plainParser{}}, parser: plainParser{}}
+			s.appendContent([]byte(tt.content))
+
+			s.appendContent([]byte(tt.then))
+
+			if got := s.content[0].message; got != tt.wantFirst {
+				t.Errorf("oldest record kept = %q, want %q", got, tt.wantFirst)
+			}
+			if s.evicted != tt.wantEvicted {
+				t.Errorf("evicted %d lines, want %d", s.evicted, tt.wantEvicted)
+			}
+		})
+	}
+}
+
+func TestTrimCopiesRarely(t *testing.T) {
+	s := &source{options: Options{MaxLines: 8}}
+	for i := range 10 {
+		s.content = append(s.content, NewLogMessage(i, "line", plainParser{}))
+	}
+
+	// Dropping a little is only a re-slice
+	array := &s.content[2]
+	s.trim()
+	if len(s.content) != 8 || &s.content[0] != array {
+		t.Fatalf("trimming 2 of 10 records copied them, or kept %d", len(s.content))
+	}
+
+	// Once more has been dropped than kept, what's kept is copied so the old array can go
+	for i := range 7 {
+		s.content = append(s.content, NewLogMessage(10+i, "line", plainParser{}))
+		s.trim()
+	}
+	if len(s.content) != 8 || s.content[0].index != 9 || s.dropped != 0 {
+		t.Errorf("kept %d records from %d, with %d dropped since copying", len(s.content), s.content[0].index, s.dropped)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func stat(t *testing.T, name string) fs.FileInfo {
+	t.Helper()
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampLayouts are the timestamp formats campfire understands, tried in order.
+// Those without a zone are taken to be in local time
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999999999",
+	"2006/01/02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/Jan/2006:15:04:05 -0700", // Apache and nginx access logs
+	"15:04:05.999999999",
+	"15:04:05",
+	"15:04",
+	"03:04:05PM",
+	"3:04:05PM",
+	"3:04PM",
+}
+
+// yearlessLayouts are formats with a month and day but no year, like syslog's. They're taken to be from the last year
+var yearlessLayouts = []string{
+	time.Stamp,
+	time.StampMicro,
+}
+
+// epochPattern matches a Unix timestamp, with or without a fraction
+var epochPattern = regexp.MustCompile(`^\d+(?:\.\d+)?$`)
+
+// parseTimestamp reads a timestamp in any of the layouts campfire understands, or as epoch seconds, millis, micros or nanos.
+// Timestamps that are only a time of day come back on the zero date, see hasDate
+func parseTimestamp(text string) (time.Time, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, false
+	}
+
+	if t, ok := parseEpoch(text); ok {
+		return t, true
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, true
+		}
+	}
+
+	for _, layout := range yearlessLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return withRecentYear(t, time.Now()), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// parseEpoch reads a Unix timestamp, working out its unit from how big it is. JSON loggers like zap
+// write these as numbers. Anything smaller than 1e9 seconds is before 2001, so it's more likely a count than a time
+func parseEpoch(text string) (time.Time, bool) {
+	if !epochPattern.MatchString(text) {
+		return time.Time{}, false
+	}
+
+	// Whole numbers are scaled exactly, since millis and nanos don't survive a round trip through a float
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		switch {
+		case n >= 1e17:
+			return time.Unix(0, n), true
+		case n >= 1e14:
+			return time.UnixMicro(n), true
+		case n >= 1e11:
+			return time.UnixMilli(n), true
+		case n >= 1e9:
+			return time.Unix(n, 0), true
+		}
+		return time.Time{}, false
+	}
+
+	// Anything with a fraction is taken to be in seconds, like zap's default epoch encoder writes
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n < 1e9 || n*1e9 > math.MaxInt64 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, int64(n*1e9)), true
+}
+
+// withRecentYear puts a timestamp that had no year in the latest year that doesn't put it in the future
+func withRecentYear(t, now time.Time) time.Time {
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+
+	return t
+}
+
+// ParseTimeBound reads a bound for the time range filter: either a duration before now like 10m or 2h,
+// or a timestamp. A bare time of day like 10:30 matches that time on any day
+func ParseTimeBound(text string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(text); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("%q is negative, give how long ago instead", text)
+		}
+		return now.Add(-d), nil
+	}
+
+	if t, ok := parseTimestamp(text); ok {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q isn't a duration (like 10m) or a timestamp (like 2006-01-02T15:04:05Z or 15:04)", text)
+}
+
+// hasDate reports whether a parsed timestamp had a date, rather than only a time of day
+func hasDate(t time.Time) bool {
+	return t.Year() != 0
+}
+
+// timeOfDay gets how far into its day a timestamp is, in local time if it has a date
+func timeOfDay(t time.Time) time.Duration {
+	if hasDate(t) {
+		t = t.In(time.Local)
+	}
+
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second +
+		time.Duration(t.Nanosecond())
+}
+
+// timeSince gets how long before now a timestamp was. If it's only a time of day, it's taken to be within the last day
+func timeSince(t, now time.Time) time.Duration {
+	if hasDate(t) {
+		return now.Sub(t)
+	}
+
+	const day = 24 * time.Hour
+	return ((timeOfDay(now)-timeOfDay(t))%day + day) % day
+}
+
+// compareTimes orders two timestamps. If either one is only a time of day, just the times of day are compared
+func compareTimes(a, b time.Time) int {
+	if !hasDate(a) || !hasDate(b) {
+		return cmp.Compare(timeOfDay(a), timeOfDay(b))
+	}
+
+	return a.Compare(b)
+}
+
+// formatTimeBound shows a time range bound compactly, leaving out the date if it's today or there isn't one
+func formatTimeBound(t, now time.Time) string {
+	if !hasDate(t) {
+		return t.Format("15:04:05")
+	}
+
+	t = t.In(time.Local)
+	if y, m, d := t.Date(); y == now.Year() && m == now.Month() && d == now.Day() {
+		return t.Format("15:04:05")
+	}
+
+	return t.Format("2006-01-02 15:04")
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watcher notifies campfire as soon as the watched file is written, created or removed.
+// It's backed by inotify on Linux (and the platform's equivalent elsewhere) via fsnotify
+type watcher struct {
+	events  *fsnotify.Watcher
+	changes chan struct{}
+}
+
+// newWatcher starts watching the file at name. The parent directory is what actually gets
+// watched, so the file being removed and created again is still noticed
+func newWatcher(name string) (*watcher, error) {
+	events, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Clean(name)
+	if err := events.Add(filepath.Dir(path)); err != nil {
+		events.Close()
+		return nil, err
+	}
+
+	w := &watcher{
+		events: events,
+
+		// Buffered by one so a burst of writes collapses into a single pending change
+		changes: make(chan struct{}, 1),
+	}
+
+	go w.run(path)
+
+	return w, nil
+}
+
+// run forwards events about the watched file until the underlying watcher gets closed
+func (w *watcher) run(path string) {
+	defer close(w.changes)
+
+	for {
+		select {
+		case event, ok := <-w.events.Events:
+			if !ok {
+				return
+			}
+
+			if filepath.Clean(event.Name) != path {
+				continue
+			}
+
+			select {
+			case w.changes <- struct{}{}:
+			default: // A change is already pending, no need to queue another
+			}
+
+		case _, ok := <-w.events.Errors:
+			// Errors (like a dropped event queue) are covered by the polling fallback
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// Close stops watching the file
+func (w *watcher) Close() error {
+	return w.events.Close()
+}
+
+// ~~ Commands ~~
+
+// startWatcher tries to set up event-driven watching. If that fails, campfire keeps polling instead
+func startWatcher(src int, name string) tea.Cmd {
+	return func() tea.Msg {
+		w, err := newWatcher(name)
+		if err != nil {
+			return watcherFailedMsg{src}
+		}
+
+		return watcherStartedMsg{source: src, watcher: w}
+	}
+}
+
+// waitForChange blocks until the watcher reports that the file changed
+func waitForChange(src int, w *watcher) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-w.changes; !ok {
+			return watcherFailedMsg{src}
+		}
+
+		return fileChangedMsg{src}
+	}
+}